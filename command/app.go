@@ -0,0 +1,65 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// App is everything a mindtick run reads from its environment,
+// tests run commands in a temp dir with a fixed clock and capture the output
+type App struct {
+	Args   []string // the command line without the program name
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Now    func() time.Time
+	Dir    string // where store.mindtick is looked up from and file arguments are relative to, the working directory when empty
+}
+
+// stdin is App.Stdin, read by `mindtick import -`
+var stdin io.Reader = os.Stdin
+
+// Exec runs mindtick with the arguments, stdio and clock of the process
+func Exec() {
+	app := App{Args: os.Args[1:], Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr, Now: time.Now}
+	app.Run()
+}
+
+// Run runs one command line, errors are printed like any other output.
+// Commands keep the state of a run in package variables, so runs must not overlap.
+func (a *App) Run() {
+	stdin = a.Stdin
+	messages.Stdout, messages.Stderr = a.Stdout, a.Stderr
+	messages.Now = a.Now
+	store.Dir = a.Dir
+
+	// nothing carries over from an earlier run in the same process
+	store.Global, output, colorSet = false, "", false
+	messages.SetTags(messages.BuiltinTags)
+	messages.SetTheme(nil)
+
+	if err := processArgs(a.Args); err != nil {
+		if !colorSet { // the options were invalid
+			setColor("auto")
+		}
+		if output != "" { // keep stdout parseable
+			fmt.Fprintln(messages.Stderr, err)
+			return
+		}
+		fmt.Fprintln(messages.Stdout, err)
+	}
+}
+
+// appPath resolves a file argument against App.Dir
+func appPath(path string) string {
+	if store.Dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(store.Dir, path)
+}
@@ -0,0 +1,335 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// session runs commands against a store in a temp dir, writing
+// each command line and its output to a transcript
+type session struct {
+	t          *testing.T
+	app        App
+	now        time.Time
+	transcript bytes.Buffer
+}
+
+func newSession(t *testing.T) *session {
+	t.Helper()
+	// keep the environment of the machine running the tests out of the output
+	for _, env := range []string{"NO_COLOR", "TERM", ThemeEnv, "MINDTICK_FALLBACK"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	s := &session{t: t, now: time.Date(2026, 3, 12, 9, 30, 0, 0, time.UTC)} // a thursday
+	s.app = App{
+		Stdin:  strings.NewReader(""),
+		Stdout: &s.transcript,
+		Stderr: &s.transcript,
+		Now:    func() time.Time { return s.now },
+		Dir:    t.TempDir(),
+	}
+	return s
+}
+
+// run runs `mindtick args...` a minute after the previous command
+func (s *session) run(args ...string) {
+	s.now = s.now.Add(time.Minute)
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if strings.ContainsAny(arg, " \"") {
+			quoted[i] = fmt.Sprintf("%q", arg)
+		}
+	}
+	fmt.Fprintf(&s.transcript, "$ mindtick %s\n", strings.Join(quoted, " "))
+	s.app.Args = args
+	s.app.Run()
+	s.app.Stdin = strings.NewReader("")
+}
+
+// file writes a file in the session dir
+func (s *session) file(name, content string) {
+	path := filepath.Join(s.app.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		s.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// cat writes a file of the session dir to the transcript
+func (s *session) cat(name string) {
+	content, err := os.ReadFile(filepath.Join(s.app.Dir, filepath.FromSlash(name)))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	fmt.Fprintf(&s.transcript, "$ cat %s\n%s", name, content)
+}
+
+// wait moves the clock forward
+func (s *session) wait(d time.Duration) {
+	s.now = s.now.Add(d)
+	fmt.Fprintf(&s.transcript, "# %s later\n", d)
+}
+
+func TestGolden(t *testing.T) {
+	tests := map[string]func(s *session){
+		"messages": func(s *session) {
+			s.run("view")
+			s.run("new")
+			s.run("win", "shipped the importer")
+			s.run("note", "-m", "api limits are 1000/min")
+			s.run("fix", "-legacy prefix still works")
+			s.run("WIN", "--", "--force is in the message")
+			s.wait(26 * time.Hour)
+			s.run("task", "write the changelog")
+			s.run("view")
+			s.run("view", "--ids", "win")
+			s.run("view", "today")
+			s.run("search", "importer")
+			s.run("edit", "1", "shipped the csv importer")
+			s.run("retag", "3", "note")
+			s.run("retime", "2", "yesterday 4pm")
+			s.run("rm", "6-2147483647")
+			s.run("rm", "4")
+			s.run("view", "--ids")
+			s.run("delete")
+			s.run("view")
+		},
+		"tasks": func(s *session) {
+			s.run("new")
+			s.run("task", "review the pr", "--due", "tomorrow", "--prio", "high")
+			s.run("task", "update dependencies", "--prio", "low")
+			s.run("task", "write release notes", "--due", "fri")
+			s.run("todo")
+			s.wait(48 * time.Hour)
+			s.run("todo")
+			s.run("tags")
+			s.run("done", "1")
+			s.run("cancel", "2")
+			s.run("view", "--status")
+			s.run("snooze")
+			s.run("todo")
+			s.run("reopen", "2")
+			s.run("done", "2", "3", "99")
+			s.run("todo")
+			s.run("note", "not a task", "--due", "fri")
+			s.run("task", "stand up", "--due", "30m")
+		},
+		"output": func(s *session) {
+			s.run("new")
+			s.run("win", "shipped it", "--at", "2026-03-11 16:00")
+			s.run("task", "ship it again", "--due", "2026-03-20")
+			s.run("view", "--json")
+			s.run("--output", "jsonl", "view", "win")
+			s.run("todo", "--output", "tsv")
+			s.run("export", "--format", "csv")
+			s.run("export", "--format", "md", "-o", "report.md")
+			s.run("edit", "1", "--json")
+			s.app.Stdin = strings.NewReader(`{"timestamp":"2026-03-01T10:00:00Z","tag":"fix","text":"imported"}` + "\n")
+			s.run("import", "-", "--format", "jsonl")
+			s.app.Stdin = strings.NewReader(`{"timestamp":"2026-03-02T10:00:00Z","tag":"today","text":"a range, not a tag"}` + "\n")
+			s.run("import", "-", "--format", "jsonl", "--create-tags")
+			s.run("view", "--output", "tsv", "fix")
+		},
+		"git": func(s *session) {
+			s.file("webshop/README.md", "")
+			s.app.Dir = filepath.Join(s.app.Dir, "webshop")
+			s.run("new")
+			s.run("note", "not a repo yet")
+			// a repo without an index, checked out on a branch
+			s.file(".git/HEAD", "ref: refs/heads/feature/x\n")
+			s.file(".git/refs/heads/feature/x", "3f2a9c1d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a39\n")
+			s.run("win", "started the feature")
+			s.file(".git/HEAD", "9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a291807\n")
+			s.run("fix", "on a detached head")
+			s.run("view", "--git")
+			s.run("view", "--branch", "feature/x")
+			s.run("view", "--branch", "main")
+			s.run("view", "win", "--branch", "main")
+			s.run("view", "--json", "--branch", "feature/x")
+		},
+		"changelog": func(s *session) {
+			s.run("new")
+			s.run("changelog")
+			s.run("win", "csv import")
+			s.run("fix", "crash on empty store")
+			s.run("note", "not in the changelog")
+			s.run("tag", "add", "release")
+			s.run("release", "v1.2")
+			s.wait(24 * time.Hour)
+			s.run("win", "pdf export")
+			s.run("task", "docs for the exporters")
+			s.run("done", "6")
+			s.run("release", "v1.3")
+			s.run("fix", "wrong week numbers")
+			s.file("CHANGELOG.md", "# Changelog\n\nWritten by hand until v1.2.\n\n## [1.3] - 2026-01-01\n\n### Added\n- a draft\n\n## [1.1] - 2025-12-01\n\n### Added\n- the first release\n\n[1.1]: https://example.com/v1.1\n")
+			s.run("changelog", "--since", "v1.2", "--releases", "--sections", "win=Added,fix=Fixed,task=Changed")
+			s.cat("CHANGELOG.md")
+			s.run("changelog", "--since", "2026-03-13", "-o", "-")
+			s.run("changelog", "--since", "yesterday", "--releases", "-o", "-")
+			s.run("changelog", "--since", "v9")
+			s.run("changelog", "--sections", "win")
+		},
+		"report": func(s *session) {
+			s.file("acme/.keep", "")
+			s.app.Dir = filepath.Join(s.app.Dir, "acme")
+			s.run("new")
+			s.run("report", "--client")
+			s.run("win", "onboarding flow", "--at", "2026-03-03 11:00")
+			s.run("win", "csv import", "--at", "last monday 10am")
+			s.run("fix", "crash on empty store", "--at", "last monday 2pm")
+			s.run("note", "the client asked about pdfs", "--at", "last tuesday")
+			s.run("win", "pdf export")
+			s.run("win", "faster search for the <admin> page", "--private")
+			s.run("task", "docs for the exporters")
+			s.run("cancel", "7")
+			s.run("fix", "wrong week numbers")
+			s.run("private", "2", "8-12")
+			s.run("public", "2")
+			s.run("report", "week")
+			s.run("report", "--client")
+			s.run("report", "--client", "--format", "md")
+			s.run("report", "--client", "-o", "progress.html")
+			s.cat("progress.html")
+			s.run("report", "win", "-o", "progress.pdf")
+			s.run("report", "note", "--client")
+			s.run("view", "today")
+		},
+		"standup": func(s *session) {
+			s.run("new")
+			s.run("standup")
+			s.wait(96 * time.Hour) // monday
+			s.run("win", "csv import", "--at", "last friday 10am")
+			s.run("work", "reviewed the <admin> pr & merged it", "--at", "last friday 3pm")
+			s.run("note", "not in the standup", "--at", "last friday 4pm")
+			s.run("ALERT", "staging is down", "--at", "last friday 5pm")
+			s.run("fix", "weekend hotfix", "--at", "yesterday 11am")
+			s.run("task", "pdf export", "--due", "tomorrow", "--prio", "high")
+			s.run("task", "docs for the exporters")
+			s.run("task", "renew the certificate", "--due", "2026-03-13")
+			s.run("task", "cancelled task")
+			s.run("cancel", "9")
+			s.run("work", "standup notes")
+			s.run("standup")
+			s.run("standup", "--format", "slack")
+			s.run("standup", "--workdays", "sun-thu")
+			s.wait(6 * 24 * time.Hour) // sunday
+			s.run("standup")
+			s.run("standup", "--workdays", "weekdays")
+			s.run("standup", "--format", "markdown")
+		},
+		"stats": func(s *session) {
+			s.run("new")
+			s.run("stats")
+			s.run("win", "csv import", "--at", "2026-03-09 09:15")
+			s.run("fix", "crash on empty store", "--at", "2026-03-09 14:00")
+			s.run("note", "api limits", "--at", "2026-03-10 09:45")
+			s.run("win", "pdf export", "--at", "2026-03-10 10:30")
+			s.run("work", "reviews", "--at", "2026-03-10 11:00")
+			s.run("win", "faster search", "--at", "yesterday 4pm")
+			s.run("task", "docs for the exporters")
+			s.run("stats")
+			s.run("stats", "win", "week")
+			s.run("stats", "2026-02")
+		},
+		"help": func(s *session) {
+			s.run("viw")
+			s.run("view", "--idz")
+			s.run("view", "--help")
+			s.run("help", "tag", "remove")
+			s.run("tag")
+			s.run("new")
+			s.run("tag", "add", "deploy", "--bg", "blue")
+			s.run("deploy", "v1.4 to production")
+			s.run("deplyo", "typo")
+			s.run("win")
+			s.run("--color", "sometimes", "view")
+		},
+	}
+	for name, script := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newSession(t)
+			script(s)
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, s.transcript.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v, run go test ./command -update to create it", err)
+			}
+			if got := s.transcript.String(); got != string(want) {
+				t.Errorf("output differs from %s, run go test ./command -update and review the diff\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// TestGlobalStore checks that only new and adding a message create the global store
+func TestGlobalStore(t *testing.T) {
+	s := newSession(t)
+	global := filepath.Join(os.Getenv("XDG_DATA_HOME"), "mindtick")
+	exists := func() bool {
+		_, err := os.Stat(global)
+		return err == nil
+	}
+
+	t.Setenv("MINDTICK_FALLBACK", "global")
+	s.file("old.jsonl", `{"timestamp":"2026-03-01T10:00:00Z","tag":"win","text":"imported"}`+"\n")
+	// reads, and adds or imports that fail or are dry runs, don't create it
+	for _, args := range [][]string{{"-g", "view"}, {"-g", "help"}, {"help"}, {"view"}, {"-g", "todo"}, {"-g", "export"},
+		{"-g", "win"}, {"-g", "task", "x", "--due", "garbage"}, {"-g", "note", "x", "--at", "garbage"}, {"-g", "import", "old.jsonl", "--dry-run"}} {
+		s.run(args...)
+		if exists() {
+			t.Fatalf("mindtick %s created the global store", strings.Join(args, " "))
+		}
+	}
+	if !strings.Contains(s.transcript.String(), "global store.mindtick not found") {
+		t.Errorf("reads should say the global store doesn't exist:\n%s", s.transcript.String())
+	}
+
+	s.run("-g", "win", "shipped it")
+	s.run("-g", "view")
+	if !exists() || !strings.Contains(s.transcript.String(), "shipped it") {
+		t.Errorf("adding a message should create the global store:\n%s", s.transcript.String())
+	}
+}
+
+// TestEmptyStore names the store that was opened when it is empty
+func TestEmptyStore(t *testing.T) {
+	s := newSession(t)
+	global := filepath.Join(os.Getenv("XDG_DATA_HOME"), "mindtick", "store.mindtick")
+	for _, run := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"new", "--jsonl"}, ""},
+		{[]string{"view"}, "mindtick.jsonl is empty"},
+		{[]string{"stats"}, "mindtick.jsonl is empty"},
+		{[]string{"-g", "new"}, ""},
+		{[]string{"-g", "view"}, global + " is empty"},
+		{[]string{"-g", "stats"}, global + " is empty"},
+	} {
+		start := s.transcript.Len()
+		s.run(run.args...)
+		if got := s.transcript.String()[start:]; !strings.Contains(got, run.want) {
+			t.Errorf("want %q in\n%s", run.want, got)
+		}
+	}
+}
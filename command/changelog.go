@@ -0,0 +1,170 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ninesl/mindtick/export"
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// sectionsSetting is the tag=Section mapping of the last `mindtick changelog --sections`
+const sectionsSetting = "changelog_sections"
+
+const defaultSections = "win=Added,fix=Fixed"
+
+var changelogFormats = []string{"keepachangelog"}
+
+// parseSections parses win=Added,fix=Fixed into the section of each tag
+func parseSections(mapping string) (map[messages.Tag]string, error) {
+	sections := map[messages.Tag]string{}
+	for _, pair := range strings.Split(mapping, ",") {
+		tagName, section, ok := strings.Cut(pair, "=")
+		tagName, section = strings.TrimSpace(tagName), strings.TrimSpace(section)
+		if !ok || tagName == "" || section == "" {
+			return nil, fmt.Errorf("invalid section mapping %s, use tag=Section pairs like %s",
+				messages.ColorizeStr(pair, messages.BrightPurple), messages.ColorizeStr(defaultSections, messages.BrightGreen))
+		}
+		def, ok := messages.LookupTag(tagName)
+		if !ok {
+			return nil, unknownTagErr(tagName)
+		}
+		sections[def.ID] = section
+	}
+	return sections, nil
+}
+
+// markerVersion is the version a release marker stands for, the first word of its text
+func markerVersion(msg messages.Message) string {
+	version, _, _ := strings.Cut(strings.TrimSpace(msg.Msg), " ")
+	return version
+}
+
+// sameVersion compares versions without a leading v
+func sameVersion(a, b string) bool {
+	return strings.TrimPrefix(strings.ToLower(a), "v") == strings.TrimPrefix(strings.ToLower(b), "v")
+}
+
+// `mindtick changelog --since v1.2 --releases -o CHANGELOG.md`
+func Changelog(in *Input) error {
+	format := strings.ToLower(in.String("format"))
+	if format == "" {
+		format = changelogFormats[0]
+	}
+	if !slices.Contains(changelogFormats, format) {
+		return fmt.Errorf("unknown changelog format %s\nvalid formats are %v",
+			messages.ColorizeStr(format, messages.BrightPurple), messages.ColorizeStr(strings.Join(changelogFormats, ", "), messages.BrightGreen))
+	}
+	outPath := in.String("out")
+	if outPath == "" {
+		outPath = "CHANGELOG.md"
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	mapping := in.String("sections")
+	if mapping == "" {
+		if mapping, err = db.Setting(sectionsSetting); err != nil {
+			return err
+		}
+	}
+	if mapping == "" {
+		mapping = defaultSections
+	}
+	sections, err := parseSections(mapping)
+	if err != nil {
+		return err
+	}
+	if in.String("sections") != "" {
+		if err := db.SetSetting(sectionsSetting, mapping); err != nil {
+			return err
+		}
+	}
+
+	marker := messages.ANYTAG // release markers are only looked for when they are used
+	if in.Bool("releases") || in.String("since") != "" {
+		markerName := in.String("marker")
+		if markerName == "" {
+			markerName = "release"
+		}
+		def, ok := messages.LookupTag(markerName)
+		if !ok && in.Bool("releases") {
+			return fmt.Errorf("unknown release marker tag %s, add it with %s",
+				messages.ColorizeStr(markerName, messages.BrightPurple), messages.ColorizeStr("mindtick tag add "+markerName, messages.BrightGreen))
+		}
+		marker = def.ID
+	}
+
+	msgs, err := db.Messages(store.Filter{Tag: messages.ANYTAG})
+	if err != nil {
+		return err
+	}
+
+	if since := in.String("since"); since != "" {
+		start := slices.IndexFunc(msgs, func(msg messages.Message) bool {
+			return marker != messages.ANYTAG && msg.Tag == marker && sameVersion(markerVersion(msg), since)
+		})
+		if start >= 0 {
+			msgs = msgs[start+1:]
+		} else {
+			r, err := store.ParseRange("since "+since, messages.Now())
+			if err != nil {
+				return fmt.Errorf("no release %s, and it isn't a date either\nmark releases with %s",
+					messages.ColorizeStr(since, messages.BrightPurple), messages.ColorizeStr("mindtick release "+since, messages.BrightGreen))
+			}
+			msgs = slices.DeleteFunc(msgs, func(msg messages.Message) bool { return msg.Timestamp.Before(r.Start) })
+		}
+	}
+
+	// releases are collected oldest first, the last one is Unreleased
+	releases := []export.Release{{}}
+	var entries int
+	for _, msg := range msgs {
+		current := &releases[len(releases)-1]
+		if in.Bool("releases") && msg.Tag == marker {
+			current.Version, current.Date = markerVersion(msg), msg.Timestamp
+			releases = append(releases, export.Release{})
+			continue
+		}
+		name, ok := sections[msg.Tag]
+		if !ok || msg.Status == messages.CANCELLED {
+			continue
+		}
+		i := slices.IndexFunc(current.Sections, func(s export.Section) bool { return s.Name == name })
+		if i < 0 {
+			current.Sections = append(current.Sections, export.Section{Name: name})
+			i = len(current.Sections) - 1
+		}
+		current.Sections[i].Entries = append(current.Sections[i].Entries, msg.Msg)
+		entries++
+	}
+	if entries == 0 {
+		return fmt.Errorf("no messages tagged %s to add to the changelog", messages.ColorizeStr(mapping, messages.BrightPurple))
+	}
+	for i := range releases {
+		export.SortSections(releases[i].Sections)
+	}
+	slices.Reverse(releases)
+
+	if outPath == "-" {
+		fmt.Fprint(rawStdout(), export.KeepAChangelog("", releases))
+		return nil
+	}
+	path := appPath(outPath)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to read %s: %v", outPath, err)
+	}
+	if err := os.WriteFile(path, []byte(export.KeepAChangelog(string(existing), releases)), 0644); err != nil {
+		return fmt.Errorf("unable to write %s: %v", outPath, err)
+	}
+	fmt.Fprintf(messages.Stdout, "%d changelog entries written to %s\n", entries, messages.ColorizeStr(outPath, messages.BrightPurple))
+	return nil
+}
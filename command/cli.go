@@ -0,0 +1,248 @@
+package command
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Flag is an option of a command, given as --name value, --name=value or -short value.
+// Flags without a Value placeholder are booleans and take no value.
+type Flag struct {
+	Name  string // without the dashes
+	Short string // one letter alias, optional
+	Value string // placeholder of the value in help, empty for booleans
+	Usage string
+}
+
+// Cmd is a mindtick command, its help is generated from Args, Summary and Flags
+type Cmd struct {
+	Name    string
+	Args    string // positional arguments shown in help, like "[tag] [range]"
+	Summary string
+	Flags   []Flag
+	Subs    []*Cmd // subcommands like `tag add`, Run is used when none is given
+	Run     func(in *Input) error
+
+	// Message commands take a message as positional arguments, so a leading
+	// argument starting with a single - is the legacy message prefix, not a flag
+	Message bool
+}
+
+var helpFlag = Flag{Name: "help", Short: "h", Usage: "show this help"}
+
+// allFlags are the flags of c followed by --help
+func (c *Cmd) allFlags() []Flag {
+	return append(slices.Clip(c.Flags), helpFlag)
+}
+
+// flag finds a flag by its long name, or its short one with short set
+func (c *Cmd) flag(name string, short bool) (Flag, bool) {
+	for _, f := range c.allFlags() {
+		if (!short && f.Name == name) || (short && f.Short != "" && f.Short == name) {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+func (c *Cmd) sub(name string) *Cmd {
+	for _, sub := range c.Subs {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// Input is a parsed command line
+type Input struct {
+	Cmd   *Cmd
+	Name  string   // the lowercased command or tag, `win` for `mindtick WIN -m "shipped"`
+	Path  string   // what was typed to run Cmd, like `mindtick tag add` or `mindtick win`
+	Args  []string // positional arguments, the legacy message prefix removed
+	flags map[string]string
+}
+
+// Bool reports if a boolean flag was given
+func (in *Input) Bool(name string) bool {
+	_, ok := in.flags[name]
+	return ok
+}
+
+// String returns the value of a flag, empty when it wasn't given
+func (in *Input) String(name string) string {
+	return in.flags[name]
+}
+
+// Message returns the message of a Message command, from -m or the
+// positional arguments after the first skip ones
+func (in *Input) Message(skip int) (string, error) {
+	rest := in.Args[min(skip, len(in.Args)):]
+	if m, ok := in.flags["message"]; ok {
+		if len(rest) > 0 {
+			return "", fmt.Errorf("%s got a message twice, use either %s or %s", in.Path, messages.ColorizeStr("-m", messages.BrightPurple), messages.ColorizeStr(strings.Join(rest, " "), messages.BrightPurple))
+		}
+		rest = []string{m}
+	}
+	msg := strings.TrimSpace(strings.Join(rest, " "))
+	if msg == "" {
+		return "", fmt.Errorf("%s must have a message, like %s\n%s", messages.ColorizeStr(in.Path, messages.BrightPurple),
+			messages.ColorizeStr(fmt.Sprintf(`%s %s"your message"`, in.Path, strings.Repeat("id ", skip)), messages.BrightGreen), in.helpMsg())
+	}
+	return msg, nil
+}
+
+// helpMsg points at the help of the command
+func (in *Input) helpMsg() string {
+	return fmt.Sprintf("use %s for more information\n", messages.ColorizeStr(in.Path+" --help", messages.BrightGreen))
+}
+
+// parse splits args into flags and positional arguments,
+// `--` ends the flags and everything after it is positional
+func parse(cmd *Cmd, path string, args []string) (*Input, error) {
+	in := &Input{Cmd: cmd, Path: path, flags: map[string]string{}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			in.Args = append(in.Args, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			in.Args = append(in.Args, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		short := !strings.HasPrefix(arg, "--")
+		f, ok := cmd.flag(name, short)
+		if !ok && short && cmd.Message {
+			// message text, only the first argument drops its - like `mindtick win -shipped it`,
+			// `mindtick win shipped -fast` keeps it
+			if len(in.Args) == 0 {
+				arg = arg[1:]
+			}
+			in.Args = append(in.Args, arg)
+			continue
+		}
+		if !ok {
+			return nil, unknownFlagErr(cmd, in, arg)
+		}
+
+		switch {
+		case f.Value == "" && hasValue:
+			return nil, fmt.Errorf("%s doesn't take a value, %s", messages.ColorizeStr("--"+f.Name, messages.BrightPurple), in.helpMsg())
+		case f.Value == "":
+			value = "true"
+		case !hasValue:
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a value, %s", messages.ColorizeStr("--"+f.Name, messages.BrightPurple), in.helpMsg())
+			}
+			i++
+			value = args[i]
+		}
+		in.flags[f.Name] = value
+	}
+	return in, nil
+}
+
+func unknownFlagErr(cmd *Cmd, in *Input, arg string) error {
+	var names []string
+	for _, f := range cmd.allFlags() {
+		names = append(names, "--"+f.Name)
+	}
+	name, _, _ := strings.Cut(arg, "=")
+	if match := closest("--"+strings.TrimLeft(name, "-"), names); match != "" {
+		return fmt.Errorf("unknown flag %s, did you mean %s?\n%s", messages.ColorizeStr(arg, messages.BrightPurple), messages.ColorizeStr(match, messages.BrightGreen), in.helpMsg())
+	}
+	return fmt.Errorf("unknown flag %s for %s\n%s", messages.ColorizeStr(arg, messages.BrightPurple), in.Path, in.helpMsg())
+}
+
+// closest returns the candidate within a few typos of s, "" when none is close enough
+func closest(s string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		if d := editDistance(s, c); d < bestDist && d < len(c)/2+1 {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// summary is the line of a command in `mindtick help`
+func (c *Cmd) summary() string {
+	if c.Args == "" {
+		return c.Summary
+	}
+	return fmt.Sprintf("%s | %s", messages.ColorizeStr(c.Args, messages.BrightPurple), c.Summary)
+}
+
+// help is the output of `mindtick <command> --help`
+func (c *Cmd) help(path string) string {
+	var sb strings.Builder
+	usage := path
+	if len(c.Subs) > 0 {
+		usage += " <command>"
+	}
+	if c.Args != "" {
+		usage += " " + c.Args
+	}
+	sb.WriteString(messages.ColorizeStr("Usage: "+usage+" [flags]", messages.BrightGreen) + "\n")
+	sb.WriteString(c.Summary + "\n")
+
+	if len(c.Subs) > 0 {
+		sb.WriteString("\nCommands\n")
+		for _, sub := range c.Subs {
+			sb.WriteString(helpLine(sub.Name, sub.summary()))
+		}
+	}
+
+	sb.WriteString("\nFlags\n")
+	var (
+		names []string
+		width int
+	)
+	flags := c.allFlags()
+	for _, f := range flags {
+		name := "    --" + f.Name
+		if f.Short != "" {
+			name = "-" + f.Short + ", --" + f.Name
+		}
+		if f.Value != "" {
+			name += " " + f.Value
+		}
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+	for i, f := range flags {
+		sb.WriteString("  " + messages.ColorizeStr(messages.PadRight(names[i], width), messages.BrightPurple) + "  " + f.Usage + "\n")
+	}
+	if c.Message {
+		sb.WriteString(fmt.Sprintf("\nThe message may be quoted, passed with %s or start with %s, %s ends the flags\n",
+			messages.ColorizeStr("-m", messages.BrightPurple), messages.ColorizeStr("-", messages.BrightPurple), messages.ColorizeStr("--", messages.BrightPurple)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+func TestParse(t *testing.T) {
+	view := &Cmd{Name: "view", Flags: withOutput(idsFlag, Flag{Name: "query", Short: "q", Value: "keywords"})}
+	msgCmd := &Cmd{Name: "<tag>", Message: true, Flags: []Flag{{Name: "message", Short: "m", Value: "text"}, {Name: "due", Value: "when"}}}
+
+	tests := []struct {
+		cmd   *Cmd
+		args  []string
+		want  []string
+		flags map[string]string
+	}{
+		{view, []string{"win", "--ids", "today"}, []string{"win", "today"}, map[string]string{"ids": "true"}},
+		{view, []string{"-q", "deploy", "--output=tsv"}, nil, map[string]string{"query": "deploy", "output": "tsv"}},
+		{view, []string{"--query=a b", "--", "--ids"}, []string{"--ids"}, map[string]string{"query": "a b"}},
+		{msgCmd, []string{"shipped", "it", "--due", "fri"}, []string{"shipped", "it"}, map[string]string{"due": "fri"}},
+		{msgCmd, []string{"-shipped", "-it"}, []string{"shipped", "-it"}, map[string]string{}},
+		{msgCmd, []string{"shipped", "-fast"}, []string{"shipped", "-fast"}, map[string]string{}},
+		{msgCmd, []string{"--due", "fri", "-shipped", "-it"}, []string{"shipped", "-it"}, map[string]string{"due": "fri"}},
+		{msgCmd, []string{"-m", "-a message"}, nil, map[string]string{"message": "-a message"}},
+		{msgCmd, []string{"--", "--due", "is a word"}, []string{"--due", "is a word"}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		in, err := parse(tt.cmd, "mindtick "+tt.cmd.Name, tt.args)
+		if err != nil {
+			t.Errorf("parse(%q): %v", tt.args, err)
+			continue
+		}
+		if !slices.Equal(in.Args, tt.want) {
+			t.Errorf("parse(%q) args = %q, want %q", tt.args, in.Args, tt.want)
+		}
+		for name, value := range tt.flags {
+			if got := in.String(name); got != value {
+				t.Errorf("parse(%q) --%s = %q, want %q", tt.args, name, got, value)
+			}
+		}
+		if len(in.flags) != len(tt.flags) {
+			t.Errorf("parse(%q) flags = %v, want %v", tt.args, in.flags, tt.flags)
+		}
+	}
+
+	for _, args := range [][]string{{"--idz"}, {"-x"}, {"--ids=yes"}, {"-q"}} {
+		if _, err := parse(view, "mindtick view", args); err == nil {
+			t.Errorf("parse(%q) should fail", args)
+		}
+	}
+	_, err := parse(view, "mindtick view", []string{"--idz"})
+	if err == nil || !strings.Contains(messages.StripANSI(err.Error()), "did you mean --ids?") {
+		t.Errorf("parse(--idz) = %v, want a --ids suggestion", err)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"view", "tags", "todo", "search"}
+	tests := map[string]string{"veiw": "view", "tgas": "tags", "serch": "search", "todo": "todo", "xyz": "", "v": ""}
+	for s, want := range tests {
+		if got := closest(s, candidates); got != want {
+			t.Errorf("closest(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// ThemeEnv names the theme to use when --theme isn't given
+const ThemeEnv = "MINDTICK_THEME"
+
+var (
+	colorModes = []string{"auto", "always", "never"}
+	colorSet   bool // setColor ran, messages.Stdout and Stderr are final
+)
+
+// colorEnabled decides if colors are printed, auto turns them off when NO_COLOR
+// is set, for dumb terminals and when stdout isn't a terminal
+func colorEnabled(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || output != "" {
+		return false
+	}
+	return isTerminal(messages.Stdout)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// setColor strips colors from messages.Stdout and Stderr unless they are enabled
+func setColor(mode string) error {
+	if mode == "" {
+		mode = "auto"
+	}
+	if !slices.Contains(colorModes, mode) {
+		return fmt.Errorf("unknown color mode %s\nvalid modes are %v",
+			messages.ColorizeStr(mode, messages.BrightPurple), messages.ColorizeStr(strings.Join(colorModes, ", "), messages.BrightGreen))
+	}
+	// unwrap the writers of an earlier Exec in the same process
+	if sw, ok := messages.Stdout.(*messages.StripWriter); ok {
+		messages.Stdout = sw.W
+	}
+	if sw, ok := messages.Stderr.(*messages.StripWriter); ok {
+		messages.Stderr = sw.W
+	}
+	if !colorEnabled(mode) {
+		messages.Stdout = &messages.StripWriter{W: messages.Stdout}
+		messages.Stderr = &messages.StripWriter{W: messages.Stderr}
+	}
+	colorSet = true
+	return nil
+}
+
+// rawStdout is messages.Stdout without color stripping, exports are written byte for byte
+func rawStdout() io.Writer {
+	if sw, ok := messages.Stdout.(*messages.StripWriter); ok {
+		return sw.W
+	}
+	return messages.Stdout
+}
+
+// themeDir is $XDG_CONFIG_HOME/mindtick/themes, defaulting to ~/.config
+func themeDir() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "mindtick", "themes")
+}
+
+// loadTheme finds a theme by name: a builtin theme, name.theme in themeDir or a path to a theme file
+func loadTheme(name string) (messages.Theme, error) {
+	if t, ok := messages.BuiltinTheme(name); ok {
+		return t, nil
+	}
+	path := name
+	if !strings.ContainsRune(name, os.PathSeparator) && !strings.HasSuffix(name, ".theme") {
+		path = filepath.Join(themeDir(), name+".theme")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unknown theme %s\nbuiltin themes are %v, or put your own in %s",
+			messages.ColorizeStr(name, messages.BrightPurple), messages.ColorizeStr(strings.Join(messages.ThemeNames(), ", "), messages.BrightGreen), themeDir())
+	}
+	defer f.Close()
+	t, err := messages.ParseTheme(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme %s, %v", messages.ColorizeStr(path, messages.BrightPurple), err)
+	}
+	return t, nil
+}
+
+// setTheme loads name, or the theme named by ThemeEnv
+func setTheme(name string) error {
+	if name == "" {
+		name = os.Getenv(ThemeEnv)
+	}
+	if name == "" {
+		return nil
+	}
+	t, err := loadTheme(name)
+	if err != nil {
+		return err
+	}
+	messages.SetTheme(t)
+	return nil
+}
+
+// `mindtick themes` shows the tags of this store in every builtin theme
+func Themes(*Input) error {
+	loadStoreTags()
+
+	var sb strings.Builder
+	sb.WriteString(helpLine("USAGE:", messages.ColorizeStr("mindtick --theme name command args", messages.BrightPurple)))
+	sb.WriteString(helpLine("", fmt.Sprintf("or set %s, your own themes go in %s", messages.ColorizeStr(ThemeEnv+"=name", messages.BrightPurple), themeDir())))
+	for _, name := range messages.ThemeNames() {
+		t, _ := messages.BuiltinTheme(name)
+		messages.SetTheme(t)
+		sb.WriteString(messages.PadRight(messages.ColorizeStr(name, messages.BrightGreen), 15))
+		for _, def := range messages.TagDefs() {
+			sb.WriteString(def.Render(" "+def.Name+" ") + " ")
+		}
+		sb.WriteString("\n")
+	}
+	messages.SetTheme(nil)
+	fmt.Fprint(messages.Stdout, sb.String())
+	return nil
+}
@@ -2,237 +2,379 @@ package command
 
 import (
 	"fmt"
-	"os"
+	"slices"
 	"strings"
 
 	_ "embed"
 
+	"github.com/ninesl/mindtick/git"
 	"github.com/ninesl/mindtick/messages"
 	"github.com/ninesl/mindtick/store"
 )
 
-func Exec() {
-	if err := processArgs(); err != nil {
-		fmt.Println(err)
-	}
-}
-
 //go:embed version
 var version string
 
 var (
-	MINDTICK      string = messages.ColorizeStr("mindtick", messages.BrightGreen)
-	Ver           string = messages.ColorizeStr(fmt.Sprintf("mindtick %s", version), messages.Bold, messages.BrightRedBg)
-	useHelpMsg           = fmt.Sprintf("use %s for more information\n", messages.ColorizeStr("mindtick help", messages.BrightGreen))
-	messagePrefix        = "-" // FIXME: how to ignore =:zsh or >:newfilem ' ", other chars, etc? custom prefix?
+	MINDTICK   string = messages.ColorizeStr("mindtick", messages.BrightGreen)
+	Ver        string = messages.ColorizeStr(fmt.Sprintf("mindtick %s", version), messages.Bold, messages.BrightRedBg)
+	useHelpMsg        = fmt.Sprintf("use %s for more information\n", messages.ColorizeStr("mindtick help", messages.BrightGreen))
 )
 
-func Version() error {
-	fmt.Println(Ver)
+func Version(*Input) error {
+	fmt.Fprintln(messages.Stdout, Ver)
 	return nil
 }
-func Help() error {
+
+// `mindtick help command` is the same as `mindtick command --help`
+func Help(in *Input) error {
+	if len(in.Args) > 0 {
+		in, err := parseCommand(append(in.Args, "--help"))
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(messages.Stdout, in.Cmd.help(in.Path))
+		return nil
+	}
+
 	var sb strings.Builder
 	sb.WriteString(Ver)
 	sb.WriteString("\nUsage\n")
 	sb.WriteString(messages.ColorizeStr("mindtick command args\n", messages.BrightGreen))
+	sb.WriteString(messages.ColorizeStr("mindtick -g command args", messages.BrightGreen))
+	sb.WriteString(fmt.Sprintf(" uses your global %s, set %s to fall back to it\n", store.COLORDBFILENAME, messages.ColorizeStr(store.FallbackEnv+"=global", messages.BrightPurple)))
+	sb.WriteString(messages.ColorizeStr("mindtick --output json|jsonl|tsv command args", messages.BrightGreen))
+	sb.WriteString(fmt.Sprintf(" prints records without colors, %s is short for json\n", messages.ColorizeStr("--json", messages.BrightPurple)))
+	sb.WriteString(messages.ColorizeStr("mindtick --color auto|always|never --theme name command args", messages.BrightGreen))
+	sb.WriteString(fmt.Sprintf(" colors are off for pipes and with %s\n", messages.ColorizeStr("NO_COLOR", messages.BrightPurple)))
 	sb.WriteString("\nCommands\n")
-	for _, cmd := range commandOrder {
-		sb.WriteString(helpLine(cmd, commandsHelp[cmd]))
+	for _, cmd := range commands {
+		sb.WriteString(helpLine(cmd.Name, cmd.summary()))
 	}
-	sb.WriteString("\nPlanned Features\n")
-	sb.WriteString(plannedFeatureLine("export {tags} {filetype}", "Export all messages to a .pdf/csv/txt file based off specific tags"))
-	sb.WriteString(plannedFeatureLine("delete <id>", "Delete a message by id"))
-	sb.WriteString(plannedFeatureLine("edit <id> <new message>", "Edit a message by id"))
-	sb.WriteString(plannedFeatureLine("{keyword}", "filter by substring"))
-	sb.WriteString(plannedFeatureLine("{YYYY-MM-DD}", "filter by date"))
+	sb.WriteString(fmt.Sprintf("\nEvery command takes %s, like %s\n", messages.ColorizeStr("--help", messages.BrightPurple), messages.ColorizeStr("mindtick view --help", messages.BrightGreen)))
 
-	fmt.Print(sb.String())
+	fmt.Fprint(messages.Stdout, sb.String())
 	return nil
 }
 
 func helpLine(MessageStrategy, description string) string {
 	return fmt.Sprintf(
-		"%10s\t%s\n",
-		messages.ColorizeStr(MessageStrategy, messages.BrightGreen),
+		"%s\t%s\n",
+		messages.PadLeft(messages.ColorizeStr(MessageStrategy, messages.BrightGreen), 10),
 		description,
 	)
 }
 
 func plannedFeatureLine(MessageStrategy, description string) string {
 	return fmt.Sprintf(
-		"%45s\t%s\n",
-		messages.ColorizeStr(MessageStrategy, messages.BrightCyan),
+		"%s\t%s\n",
+		messages.PadLeft(messages.ColorizeStr(MessageStrategy, messages.BrightCyan), 36),
 		description,
 	)
 }
 
-func Ranges() error {
+func Ranges(*Input) error {
+	now := messages.Now()
+	if output != "" {
+		var records []rangeRecord
+		for _, name := range append(append([]string{}, store.RangeNames...), store.RangeExamples...) {
+			r, _ := store.ParseRange(name, now)
+			records = append(records, newRangeRecord(name, r.Start, r.End))
+		}
+		return writeRanges(records)
+	}
+
 	var sb strings.Builder
 	sb.WriteString(helpLine("USAGE: ", messages.ColorizeStr("mindtick view range", messages.BrightPurple)))
 	sb.WriteString(helpLine("", messages.ColorizeStr("mindtick view range tag", messages.BrightPurple)))
-	for i := range len(store.RangeOrder) {
-		date := messages.RenderDate(store.RangeToTime[store.RangeOrder[i]]())
-		sb.WriteString(plannedFeatureLine(store.RangeToStr[store.RangeOrder[i]], fmt.Sprintf("filter messages now to %s", date)))
+	for _, name := range store.RangeNames {
+		r, _ := store.ParseRange(name, now)
+		sb.WriteString(plannedFeatureLine(name, fmt.Sprintf("filter messages now to %s", messages.RenderDate(r.Start))))
 	}
-	fmt.Print(sb.String())
+	sb.WriteString("\n")
+	for _, example := range store.RangeExamples {
+		r, _ := store.ParseRange(example, now)
+		sb.WriteString(plannedFeatureLine(example, r.String()))
+	}
+	fmt.Fprint(messages.Stdout, sb.String())
 	return nil
 }
 
-func Tags() error {
+func Tags(*Input) error {
+	loadStoreTags()
+	if output != "" {
+		return writeTags(messages.TagDefs())
+	}
+
 	var sb strings.Builder
-	sb.WriteString(helpLine("USAGE:", messages.ColorizeStr("mindtick tag -your message", messages.BrightPurple)))
+	sb.WriteString(helpLine("USAGE:", messages.ColorizeStr(`mindtick tag "your message"`, messages.BrightPurple)))
 	sb.WriteString(helpLine("", messages.ColorizeStr("mindtick view tag", messages.BrightPurple)))
 	sb.WriteString(helpLine("", messages.ColorizeStr("mindtick view tag range", messages.BrightPurple)))
-	for _, tag := range messages.TagOrder {
-		sb.WriteString(messages.Tags[tag] + " ")
+	sb.WriteString(helpLine("", messages.ColorizeStr("mindtick tag add|rename|remove|list", messages.BrightPurple)))
+	for _, def := range messages.TagDefs() {
+		sb.WriteString(def.Render(" "+def.Name+" ") + " ")
 	}
-	fmt.Println(sb.String())
+	fmt.Fprintln(messages.Stdout, sb.String())
 	return nil
 }
 
-var (
-	commands = map[string]func() error{
-		"help":    Help,
-		"version": Version,
-		"new":     store.New,
-		"delete":  store.Delete,
-		"tag":     AddMessage,
-		"view":    View,
-		"tags":    Tags,
-		"ranges":  Ranges,
-	}
-	commandsHelp = map[string]string{
-		"help":    "Display this help message",
-		"version": fmt.Sprintf("Display the current version of %s", MINDTICK),
-		"new":     fmt.Sprintf("Create a new %s file in the current directory", store.COLORDBFILENAME),
-		"delete":  fmt.Sprintf("Delete the %s file in the current directory", store.COLORDBFILENAME),
-		"tag":     fmt.Sprintf("%s | adds a message", messages.ColorizeStr("-your message", messages.BrightPurple)),
-		"view":    fmt.Sprintf("optional: %s | Display messages by tag and/or range", messages.ColorizeStr("tag range", messages.BrightPurple)),
-		"tags":    fmt.Sprintf("Display all available tags, used in %s and %s", messages.ColorizeStr("view", messages.BrightGreen), messages.ColorizeStr("tag", messages.BrightGreen)),
-		"ranges":  "Display all available ranges",
-	}
-	commandOrder = []string{"version", "help", "new", "delete", "tag", "view", "tags", "ranges"}
-)
+// `mindtick new [--jsonl]`
+func New(in *Input) error {
+	if in.Bool("jsonl") {
+		return store.New(store.JSONLFileName)
+	}
+	return store.New(store.DBFileName)
+}
 
-func processArgs() error {
-	if len(os.Args) < 2 {
-		return fmt.Errorf("mindtick requires at least one argument, %s", useHelpMsg)
+// loadStoreTags loads the tags of the nearest store, keeping the builtin tags if there is none
+func loadStoreTags() {
+	if db, err := store.LoadMindtick(); err == nil {
+		db.Close()
 	}
+}
 
-	if _, ok := messages.StrToTag[strings.ToLower(os.Args[1])]; ok { // case insensitivity for tags
-		return commands["tag"]()
+func processArgs(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("mindtick requires at least one argument, %s", useHelpMsg)
 	}
 
-	if len(os.Args) > 1 {
-		if cmd, ok := commands[os.Args[1]]; ok {
-			return cmd()
+	// global options come before the command so they can't clash with message text
+	var colorMode, themeName string
+options:
+	for len(args) > 0 {
+		option, value, hasValue := strings.Cut(args[0], "=")
+		switch option {
+		case "-g", "--global":
+			store.Global = true
+		case "--json":
+			output = "json"
+		case "--output", "--color", "--theme":
+			if !hasValue {
+				if len(args) < 2 {
+					return fmt.Errorf("%s requires a value, %s", messages.ColorizeStr(option, messages.BrightPurple), useHelpMsg)
+				}
+				value, args = args[1], args[1:]
+			}
+			switch option {
+			case "--output":
+				if err := setOutput(value); err != nil {
+					return err
+				}
+			case "--color":
+				colorMode = strings.ToLower(value)
+			case "--theme":
+				themeName = value
+			}
+		default:
+			break options
 		}
-	} else {
+		args = args[1:]
+	}
+	if len(args) < 1 {
 		return fmt.Errorf("mindtick requires at least one argument, %s", useHelpMsg)
 	}
 
-	return fmt.Errorf("unknown mindtick argument %s, %s", messages.ColorizeStr(strings.Join(os.Args[1:], " "), messages.BrightPurple), useHelpMsg)
-}
-
-func View() error {
-	args := os.Args
-	size := len(args)
+	in, err := parseCommand(args)
+	if err != nil {
+		return err
+	}
 
-	if size > 4 {
-		return fmt.Errorf("too many arguments for view, %s", useHelpMsg)
+	// read commands also take --json and --output after the command
+	if in.Bool("json") {
+		output = "json"
+	}
+	if format := in.String("output"); format != "" {
+		if err := setOutput(format); err != nil {
+			return err
+		}
+	}
+	if _, ok := in.Cmd.flag("output", false); !ok && output != "" {
+		return fmt.Errorf("%s only works with %s", messages.ColorizeStr("--output", messages.BrightPurple), messages.ColorizeStr(strings.Join(outputCommands(), ", "), messages.BrightGreen))
 	}
 
-	db, err := store.LoadMindtick()
-	if err != nil {
+	if err := setColor(colorMode); err != nil {
+		return err
+	}
+	if err := setTheme(themeName); err != nil {
 		return err
 	}
 
-	if size == 2 { // default behavior
-		msgs, err := store.Messages(db, messages.ANYTAG, store.ANYTIME)
-		if err != nil {
-			return err
+	if in.Bool("help") {
+		fmt.Fprint(messages.Stdout, in.Cmd.help(in.Path))
+		return nil
+	}
+	if in.Cmd.Run == nil { // a command like `tag` without its subcommand
+		if len(in.Args) > 0 {
+			return unknownCommandErr(in.Args[0], in.Cmd.Subs, in.Path)
 		}
+		return fmt.Errorf("%s", in.Cmd.help(in.Path))
+	}
+
+	if !slices.Contains(bannerless, in.Name) {
+		overdueBanner(messages.Now())
+	}
+	return in.Cmd.Run(in)
+}
 
-		if len(msgs) == 0 {
-			return fmt.Errorf("%s is empty, %s", messages.ColorizeStr(store.DBFileName, messages.BrightRed), useHelpMsg)
+// parseCommand finds the command named by args[0], a tag adds a message,
+// and parses the rest of args with its flags
+func parseCommand(args []string) (*Input, error) {
+	name, path := args[0], "mindtick "+args[0]
+	cmd := lookupCommand(name)
+	if cmd == nil {
+		// custom tags live in the store
+		loadStoreTags()
+		if _, ok := messages.LookupTag(name); !ok { // case insensitivity for tags
+			return nil, unknownCommandErr(name, commands, "mindtick")
 		}
-		messages.RenderMessages(msgs...)
-		return nil
+		cmd = addCmd
+	}
+	args = args[1:]
+	for len(args) > 0 && cmd.sub(args[0]) != nil {
+		cmd, path, args = cmd.sub(args[0]), path+" "+args[0], args[1:]
 	}
 
-	rangeType := store.StrToRange[args[2]]
-	msgType := messages.StrToTag[args[2]]
+	in, err := parse(cmd, path, args)
+	if err != nil {
+		return nil, err
+	}
+	in.Name = strings.ToLower(name)
+	return in, nil
+}
 
-	if rangeType == store.ANYTIME && msgType == messages.ANYTAG {
-		return fmt.Errorf("unknown view argument %s, %s", messages.ColorizeStr(args[2], messages.BrightPurple), useHelpMsg)
+// unknownCommandErr suggests the closest command or tag to name
+func unknownCommandErr(name string, cmds []*Cmd, path string) error {
+	helpMsg := useHelpMsg
+	if path != "mindtick" {
+		helpMsg = fmt.Sprintf("use %s for more information\n", messages.ColorizeStr(path+" --help", messages.BrightGreen))
+	}
+	var names []string
+	for _, cmd := range cmds {
+		if cmd != addCmd {
+			names = append(names, cmd.Name)
+		}
+	}
+	if path == "mindtick" {
+		for _, def := range messages.TagDefs() {
+			names = append(names, def.Name)
+		}
 	}
+	if match := closest(strings.ToLower(name), names); match != "" {
+		return fmt.Errorf("unknown mindtick argument %s, did you mean %s?\n%s", messages.ColorizeStr(name, messages.BrightPurple), messages.ColorizeStr(path+" "+match, messages.BrightGreen), helpMsg)
+	}
+	return fmt.Errorf("unknown mindtick argument %s, %s", messages.ColorizeStr(name, messages.BrightPurple), helpMsg)
+}
 
-	if size == 4 {
-		// if range is not found, check if it's a tag
-		if rangeType == store.ANYTIME {
-			rangeType = store.StrToRange[args[3]]
-			if rangeType == store.ANYTIME {
-				var ranges []string
-				for k := range store.StrToRange {
-					ranges = append(ranges, k)
-				}
-				return fmt.Errorf("unknown view range %s\nvalid ranges are %v", messages.ColorizeStr(args[3], messages.BrightPurple), messages.ColorizeStr(strings.Join(ranges, ", "), messages.BrightGreen))
-			}
-		} else if msgType == messages.ANYTAG {
-			msgType = messages.StrToTag[args[3]]
-			if msgType == messages.ANYTAG {
-				var msgTypes []string
-				for k := range messages.StrToTag {
-					msgTypes = append(msgTypes, k)
-				}
-				return fmt.Errorf("unknown view tag %s\nvalid tags are %v", messages.ColorizeStr(args[3], messages.BrightPurple), messages.ColorizeStr(strings.Join(msgTypes, ", "), messages.BrightGreen))
-			}
+// parseFilter reads `view` style arguments: the first tag filters by tag,
+// everything else is the range expression. Tags must be loaded first.
+func parseFilter(args []string) (store.Filter, error) {
+	filter := store.Filter{Tag: messages.ANYTAG}
+	var rangeArgs []string
+	for _, arg := range args {
+		if def, ok := messages.LookupTag(arg); ok && filter.Tag == messages.ANYTAG {
+			filter.Tag = def.ID
+			continue
 		}
+		rangeArgs = append(rangeArgs, arg)
+	}
+
+	var err error
+	filter.Range, err = store.ParseRange(strings.Join(rangeArgs, " "), messages.Now())
+	return filter, err
+}
+
+func View(in *Input) error {
+	args, search := in.Args, in.String("query")
+	var query string
+	if search != "" {
+		query = store.SearchQuery(search)
 	}
+	opts := messages.RenderOptions{IDs: in.Bool("ids"), Status: in.Bool("status"), Git: in.Bool("git"), Highlight: store.HighlightTerms(query)}
 
-	msgs, err := store.Messages(db, msgType, rangeType)
+	db, err := store.LoadMindtick()
 	if err != nil {
 		return err
 	}
+	defer db.Close()
 
-	if len(msgs) == 0 {
-		return fmt.Errorf("no messages found with %s", messages.ColorizeStr(strings.Join(os.Args[2:], " "), messages.BrightPurple))
+	filter, err := parseFilter(args)
+	if err != nil {
+		return err
 	}
-	messages.RenderMessages(msgs...)
-	return nil
-}
+	filter.Query = query
+	filter.Branch = in.String("branch")
 
-func AddMessage() error {
-	tagCmd := strings.ToLower(os.Args[1]) // case insensitivity for tags
-	db, err := store.LoadMindtick()
+	msgs, err := db.Messages(filter)
 	if err != nil {
 		return err
 	}
-	if len(os.Args) < 3 {
-		return fmt.Errorf("mindtick %s must have a message, %s", messages.ColorizeStr(tagCmd, messages.BrightPurple), useHelpMsg)
+	if output != "" {
+		return writeMessages(msgs)
 	}
-	if os.Args[2][0:1] != messagePrefix {
-		tip := fmt.Sprintf("mindtick %s %v%s", tagCmd, messagePrefix, strings.Join(os.Args[2:], " "))
-		return fmt.Errorf("mindtick messages must start with %v\nexample usage: %s\n%s", messages.ColorizeStr(messagePrefix, messages.BrightGreen), messages.ColorizeStr(tip, messages.BrightGreen), useHelpMsg)
+
+	if len(msgs) == 0 {
+		if len(args) == 0 && query == "" && filter.Branch == "" { // default behavior
+			return emptyStoreErr(db)
+		}
+		var found string
+		if filters := strings.TrimSpace(strings.Join(append(args, search), " ")); filters != "" {
+			found = " with " + messages.ColorizeStr(filters, messages.BrightPurple)
+		}
+		if filter.Branch != "" {
+			found += " on branch " + messages.ColorizeStr(filter.Branch, messages.BrightPurple)
+		}
+		return fmt.Errorf("no messages found%s", found)
 	}
+	messages.RenderMessagesWith(opts, msgs...)
+	return nil
+}
 
-	// fmt.Println(os.Args)
-	os.Args[2] = strings.Replace(os.Args[2], messagePrefix, "", 1)
+// emptyStoreErr names the store that was opened, it isn't always the store.mindtick of this directory
+func emptyStoreErr(db store.Store) error {
+	return fmt.Errorf("%s is empty, %s", messages.ColorizeStr(store.ShortPath(db.Path()), messages.BrightRed), useHelpMsg)
+}
 
-	// concat all arguments after the msgType
-	var argMsgs []string
-	for i := 2; i < len(os.Args); i++ {
-		argMsgs = append(argMsgs, os.Args[i])
+// gitState is the git work tree mindtick runs in, what can't be read from .git is left empty
+func gitState() messages.Git {
+	repo, err := git.Find(appPath("."))
+	if err != nil {
+		return messages.Git{}
 	}
+	state := messages.Git{Repo: repo.Name()}
+	state.Branch, state.Commit, _ = repo.Head()
+	state.Dirty, _ = repo.Dirty()
+	return state
+}
 
-	var argMsg = strings.Join(argMsgs, " ")
-	msg, err := messages.NewMessage(tagCmd, argMsg)
+// AddMessage opens the store once the message is valid, a failed add doesn't create the global store
+func AddMessage(in *Input) error {
+	argMsg, err := in.Message(0)
+	if err != nil {
+		return err
+	}
+	at := in.String("at")
+	msg, err := messages.NewMessage(in.Name, argMsg)
 	if err != nil {
 		return fmt.Errorf("%s, %s", messages.ColorizeStr(err.Error(), messages.BrightRed), useHelpMsg)
 	}
+	now := msg.Timestamp
+	if at != "" {
+		if msg.Timestamp, err = store.ParseTime(at, now); err != nil {
+			return err
+		}
+	}
+	if err := taskOptions(&msg, in.String("due"), in.String("prio"), now); err != nil {
+		return err
+	}
+	msg.Git = gitState()
+	msg.Private = in.Bool("private")
 
-	err = store.AddMessage(db, msg)
+	db, err := store.LoadOrCreateMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	msg, err = db.AddMessage(msg)
 	if err != nil {
 		return fmt.Errorf("%s, %s", messages.ColorizeStr(err.Error(), messages.BrightRed), useHelpMsg)
 	}
@@ -242,6 +384,9 @@ func AddMessage() error {
 	//	print message (msg not loading the whole msg from the DB)
 	// else
 	// 	return error
-	fmt.Println(messages.RenderMsg(msg, false))
+	if at != "" { // the time alone doesn't say which day it landed on
+		fmt.Fprintln(messages.Stdout, messages.RenderDate(msg.Timestamp))
+	}
+	fmt.Fprintln(messages.Stdout, messages.RenderMsg(msg, false)+renderSchedule(msg, now))
 	return nil
 }
@@ -0,0 +1,181 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// outputFlags are the flags of the read commands, see setOutput
+var outputFlags = []Flag{
+	{Name: "json", Usage: "print json records without colors"},
+	{Name: "output", Value: "format", Usage: "print json, jsonl or tsv records without colors"},
+}
+
+func withOutput(flags ...Flag) []Flag {
+	return append(flags, outputFlags...)
+}
+
+var idsFlag = Flag{Name: "ids", Usage: "show the id of each message, used by edit, rm and retag"}
+
+// addCmd adds a message under any tag, `mindtick <tag>`
+var addCmd = &Cmd{
+	Name:    "<tag>",
+	Args:    `"your message"`,
+	Summary: "adds a message, optionally backdated",
+	Message: true,
+	Flags: []Flag{
+		{Name: "message", Short: "m", Value: "text", Usage: "the message, instead of positional arguments"},
+		{Name: "at", Value: "time", Usage: `when it happened, like "yesterday 4pm", "2h ago" or "2026-10-01 09:30"`},
+		{Name: "due", Value: "when", Usage: "tasks only, due by fri, tomorrow, 3d, eow or a date"},
+		{Name: "prio", Value: "priority", Usage: "tasks only, low, normal or high"},
+		{Name: "private", Usage: "leave the message out of client reports"},
+	},
+	Run: AddMessage,
+}
+
+// commands in the order `mindtick help` lists them, set in init because
+// Help and validTagName look commands up
+var commands []*Cmd
+
+func init() {
+	commands = []*Cmd{
+		{Name: "version", Summary: fmt.Sprintf("Display the current version of %s", MINDTICK), Run: Version},
+		{Name: "help", Args: "[command]", Summary: "Display this help message, or the flags of a command", Run: Help},
+		{
+			Name: "new", Summary: fmt.Sprintf("Create a new %s file in the current directory", store.COLORDBFILENAME),
+			Flags: []Flag{{Name: "jsonl", Usage: fmt.Sprintf("create an append only %s log instead, made to be committed", store.JSONLFileName)}},
+			Run:   New,
+		},
+		{Name: "delete", Summary: fmt.Sprintf("Delete the %s file in the current directory", store.COLORDBFILENAME), Run: func(*Input) error { return store.Delete() }},
+		addCmd,
+		{
+			Name: "view", Args: "[tag] [range]", Summary: "Display messages by tag and/or range",
+			Flags: withOutput(idsFlag,
+				Flag{Name: "status", Usage: "strike through done tasks, cancelled ones are also dimmed"},
+				Flag{Name: "query", Short: "q", Value: "keywords", Usage: "only messages matching a full text search"},
+				Flag{Name: "branch", Value: "name", Usage: "only messages added on a git branch"},
+				Flag{Name: "git", Usage: "show the repo, branch and commit of each message, * marks uncommitted changes"},
+			),
+			Run: View,
+		},
+		{
+			Name: "search", Args: "keywords", Summary: `full text search, supports dep* "a phrase" AND OR NOT`,
+			Flags: withOutput(idsFlag),
+			Run:   Search,
+		},
+		{
+			Name: "export", Args: "[tag] [range]", Summary: "export messages to a file or stdout",
+			Flags: []Flag{
+				{Name: "format", Value: "format", Usage: "csv, tsv, json, jsonl, md, html, txt or pdf, the extension of -o by default"},
+				{Name: "out", Short: "o", Value: "file", Usage: "write to file instead of stdout"},
+			},
+			Run: Export,
+		},
+		{
+			Name: "stats", Args: "[tag] [range]", Summary: "counts per tag, weekday and hour, the busiest days and logging streaks",
+			Run: Stats,
+		},
+		{
+			Name: "standup", Summary: "yesterday's wins, fixes and work, open tasks for today and alerts as blockers, ready to paste",
+			Flags: []Flag{
+				{Name: "format", Value: "format", Usage: "plain or slack"},
+				{Name: "workdays", Value: "days", Usage: "the days you work, like sun-thu or mon,wed,fri, " + store.DefaultWorkWeek + " by default, remembered for the next standup"},
+			},
+			Run: Standup,
+		},
+		{
+			Name: "report", Args: "[tag] [range]", Summary: "a summary with counts per tag and the messages of each week",
+			Flags: []Flag{
+				{Name: "client", Usage: "only count wins and fixes and list the wins, private messages are left out"},
+				{Name: "format", Value: "format", Usage: "md, html or txt, the extension of -o by default"},
+				{Name: "out", Short: "o", Value: "file", Usage: "write to file instead of stdout"},
+			},
+			Run: Report,
+		},
+		{
+			Name: "changelog", Summary: "write the win and fix messages to CHANGELOG.md, replacing the releases it already has",
+			Flags: []Flag{
+				{Name: "since", Value: "version", Usage: "only messages after a release marker, or since a date like 2026-03-01"},
+				{Name: "releases", Usage: "a release for every marker instead of putting everything under Unreleased"},
+				{Name: "marker", Value: "tag", Usage: `the tag of release markers, like "mindtick release v1.3", release by default`},
+				{Name: "sections", Value: "tag=Section,...", Usage: "the section of each tag, " + defaultSections + " by default, remembered for the next changelog"},
+				{Name: "format", Value: "format", Usage: "keepachangelog"},
+				{Name: "out", Short: "o", Value: "file", Usage: "CHANGELOG.md by default, - prints it"},
+			},
+			Run: Changelog,
+		},
+		{
+			Name: "import", Args: "file", Summary: "import a csv, json or jsonl file, - reads stdin, skipping duplicates",
+			Flags: []Flag{
+				{Name: "create-tags", Usage: "add tags the store doesn't have yet"},
+				{Name: "dry-run", Usage: "show what would be imported without writing anything"},
+				{Name: "format", Value: "format", Usage: "csv, json or jsonl, the extension of file by default"},
+			},
+			Run: Import,
+		},
+		{Name: "tags", Summary: fmt.Sprintf("Display all available tags, used in %s and %s", messages.ColorizeStr("view", messages.BrightGreen), messages.ColorizeStr("<tag>", messages.BrightGreen)), Flags: withOutput(), Run: Tags},
+		{
+			Name: "tag", Summary: fmt.Sprintf("manage the tags of this %s", store.COLORDBFILENAME),
+			Subs: []*Cmd{
+				{
+					Name: "add", Args: "name", Summary: "add a custom tag",
+					Flags: []Flag{
+						{Name: "bg", Value: "color", Usage: "background color, bright-black by default"},
+						{Name: "fg", Value: "color", Usage: "text color, white by default"},
+					},
+					Run: tagAdd,
+				},
+				{Name: "rename", Args: "name new-name", Summary: "rename a custom tag, its messages keep it", Run: tagRename},
+				{
+					Name: "remove", Args: "name", Summary: "remove a custom tag, its messages must be moved or deleted",
+					Flags: []Flag{
+						{Name: "reassign", Value: "tag", Usage: "move the messages of the tag to another tag"},
+						{Name: "purge", Usage: "delete the messages of the tag"},
+					},
+					Run: tagRemove,
+				},
+				{Name: "list", Summary: "same as tags", Flags: withOutput(), Run: Tags},
+			},
+		},
+		{
+			Name: "hook", Summary: "log commits with git hooks",
+			Subs: []*Cmd{
+				{
+					Name: "install", Summary: fmt.Sprintf("add post-commit and post-merge hooks to the repository of the nearest %s, existing hooks keep running", store.COLORDBFILENAME),
+					Flags: []Flag{{Name: "tag", Value: "tag", Usage: "the tag of logged commits, work by default"}},
+					Run:   hookInstall,
+				},
+				{Name: "uninstall", Summary: "remove the hooks, restoring the ones they replaced", Run: hookUninstall},
+			},
+		},
+		{Name: "ranges", Summary: "Display all available ranges", Flags: withOutput(), Run: Ranges},
+		{Name: "themes", Summary: fmt.Sprintf("Display the tags in every theme, pick one with %s", messages.ColorizeStr("--theme name", messages.BrightPurple)), Run: Themes},
+		{
+			Name: "edit", Args: `id "new message"`, Summary: "replace the text of a message", Message: true,
+			Flags: []Flag{{Name: "message", Short: "m", Value: "text", Usage: "the new message, instead of positional arguments"}},
+			Run:   Edit,
+		},
+		{Name: "rm", Args: "id 12-15", Summary: "remove messages by id or id range", Run: Remove},
+		{Name: "retag", Args: "id tag", Summary: "change the tag of a message", Run: Retag},
+		{Name: "retime", Args: `id "yesterday 4pm"`, Summary: "change when a message happened", Run: Retime},
+		{Name: "private", Args: "id 12-15", Summary: "leave messages out of client reports", Run: Private},
+		{Name: "public", Args: "id 12-15", Summary: "show private messages in client reports again", Run: Public},
+		{Name: "todo", Summary: "List open tasks by due date and priority", Flags: withOutput(), Run: Todo},
+		{Name: "done", Args: "id", Summary: "mark tasks as done", Run: Done},
+		{Name: "cancel", Args: "id", Summary: "mark tasks as cancelled", Run: Cancel},
+		{Name: "reopen", Args: "id", Summary: "reopen done or cancelled tasks", Run: Reopen},
+		{Name: "snooze", Args: "[tomorrow|fri|2h|off]", Summary: "hide the overdue banner until then, today by default", Run: Snooze},
+	}
+}
+
+// lookupCommand finds a command by name, tags are not commands
+func lookupCommand(name string) *Cmd {
+	for _, cmd := range commands {
+		if cmd.Name == name && cmd != addCmd {
+			return cmd
+		}
+	}
+	return nil
+}
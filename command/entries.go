@@ -0,0 +1,258 @@
+package command
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// parseID parses a single message id argument
+func parseID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid message id %s, %s", messages.ColorizeStr(arg, messages.BrightPurple), useHelpMsg)
+	}
+	return id, nil
+}
+
+// parseIDRange parses "12" or "12-15" into the ids it covers
+func parseIDRange(arg string) (store.IDRange, error) {
+	from, to, isRange := strings.Cut(arg, "-")
+	if !isRange {
+		id, err := parseID(arg)
+		return store.IDRange{From: id, To: id}, err
+	}
+
+	start, err := parseID(from)
+	if err != nil {
+		return store.IDRange{}, err
+	}
+	end, err := parseID(to)
+	if err != nil {
+		return store.IDRange{}, err
+	}
+	if end < start {
+		return store.IDRange{}, fmt.Errorf("invalid id range %s, start must not be after end", messages.ColorizeStr(arg, messages.BrightPurple))
+	}
+	return store.IDRange{From: start, To: end}, nil
+}
+
+func renderChange(before, after messages.Message) {
+	fmt.Fprintln(messages.Stdout, messages.RenderMsg(before, false))
+	fmt.Fprintln(messages.Stdout, messages.RenderMsg(after, false))
+}
+
+// `mindtick edit <id> "new message"`
+func Edit(in *Input) error {
+	if len(in.Args) < 1 {
+		return fmt.Errorf("mindtick %s requires an id, %s", messages.ColorizeStr("edit", messages.BrightPurple), in.helpMsg())
+	}
+	id, err := parseID(in.Args[0])
+	if err != nil {
+		return err
+	}
+	text, err := in.Message(1)
+	if err != nil {
+		return err
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	before, err := db.Message(id)
+	if err != nil {
+		return err
+	}
+	if err := db.EditMessage(id, text); err != nil {
+		return err
+	}
+
+	after := before
+	after.Msg = text
+	renderChange(before, after)
+	return nil
+}
+
+// `mindtick retag <id> tag`
+func Retag(in *Input) error {
+	if len(in.Args) != 2 {
+		return fmt.Errorf("mindtick %s requires an id and a tag, %s", messages.ColorizeStr("retag", messages.BrightPurple), in.helpMsg())
+	}
+	id, err := parseID(in.Args[0])
+	if err != nil {
+		return err
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	def, ok := messages.LookupTag(in.Args[1])
+	if !ok {
+		return unknownTagErr(in.Args[1])
+	}
+	tag := def.ID
+
+	before, err := db.Message(id)
+	if err != nil {
+		return err
+	}
+	if err := db.ChangeTag(id, tag); err != nil {
+		return err
+	}
+
+	after := before
+	after.Tag = tag
+	renderChange(before, after)
+	return nil
+}
+
+// `mindtick rm <id> <id-id> ...`
+func Remove(in *Input) error {
+	if len(in.Args) < 1 {
+		return fmt.Errorf("mindtick %s requires at least one id, %s", messages.ColorizeStr("rm", messages.BrightPurple), in.helpMsg())
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	msgs, err := messagesByID(db, in.Args)
+	if err != nil {
+		return err
+	}
+	if err := db.DeleteMessages(messageIDs(msgs)); err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		fmt.Fprintln(messages.Stdout, messages.RenderMsg(msg, false))
+	}
+	fmt.Fprintln(messages.Stdout, messages.ColorizeStr(fmt.Sprintf("%d removed", len(msgs)), messages.BrightPurple))
+	return nil
+}
+
+// messagesByID loads the messages of id and id range arguments, ids inside
+// a range that don't exist are skipped, single ids must exist
+func messagesByID(db store.Store, args []string) ([]messages.Message, error) {
+	var msgs []messages.Message
+	seen := map[int]bool{}
+	for _, arg := range args {
+		ids, err := parseIDRange(arg)
+		if err != nil {
+			return nil, err
+		}
+		var found []messages.Message
+		if ids.From == ids.To {
+			msg, err := db.Message(ids.From)
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, msg)
+		} else if found, err = db.Messages(store.Filter{IDs: ids}); err != nil {
+			return nil, err
+		}
+		slices.SortFunc(found, func(a, b messages.Message) int { return a.ID - b.ID })
+		for _, msg := range found {
+			if !seen[msg.ID] {
+				seen[msg.ID] = true
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("no messages found with %s", messages.ColorizeStr(strings.Join(args, " "), messages.BrightPurple))
+	}
+	return msgs, nil
+}
+
+func messageIDs(msgs []messages.Message) []int {
+	ids := make([]int, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.ID
+	}
+	return ids
+}
+
+// setPrivate is `mindtick private|public <id>...`
+func setPrivate(in *Input, private bool) error {
+	if len(in.Args) < 1 {
+		return fmt.Errorf("mindtick %s requires at least one id, %s", messages.ColorizeStr(in.Name, messages.BrightPurple), in.helpMsg())
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	msgs, err := messagesByID(db, in.Args)
+	if err != nil {
+		return err
+	}
+	var changed []int
+	for _, msg := range msgs {
+		if msg.Private != private {
+			changed = append(changed, msg.ID)
+		}
+	}
+	if len(changed) > 0 {
+		if err := db.SetPrivate(changed, private); err != nil {
+			return err
+		}
+	}
+	for _, msg := range msgs {
+		msg.Private = private
+		fmt.Fprintln(messages.Stdout, messages.RenderMsg(msg, false))
+	}
+	return nil
+}
+
+func Private(in *Input) error { return setPrivate(in, true) }
+func Public(in *Input) error  { return setPrivate(in, false) }
+
+// `mindtick retime <id> "yesterday 4pm"`
+func Retime(in *Input) error {
+	if len(in.Args) < 2 {
+		return fmt.Errorf("mindtick %s requires an id and a time, %s", messages.ColorizeStr("retime", messages.BrightPurple), in.helpMsg())
+	}
+	id, err := parseID(in.Args[0])
+	if err != nil {
+		return err
+	}
+	timestamp, err := store.ParseTime(strings.Join(in.Args[1:], " "), messages.Now())
+	if err != nil {
+		return err
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	before, err := db.Message(id)
+	if err != nil {
+		return err
+	}
+	if err := db.ChangeTimestamp(id, timestamp); err != nil {
+		return err
+	}
+
+	after := before
+	after.Timestamp = timestamp
+	// the dates are shown because a message may move to another day
+	fmt.Fprintln(messages.Stdout, messages.RenderDate(before.Timestamp)+" "+messages.RenderMsg(before, false))
+	fmt.Fprintln(messages.Stdout, messages.RenderDate(after.Timestamp)+" "+messages.RenderMsg(after, false))
+	return nil
+}
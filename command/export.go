@@ -0,0 +1,82 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ninesl/mindtick/export"
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// reportTitle names a report after the directory of the store
+func reportTitle() string {
+	if store.Global {
+		return "mindtick"
+	}
+	dbPath, err := store.FindMindtick()
+	if err != nil {
+		return "mindtick"
+	}
+	return filepath.Base(filepath.Dir(dbPath))
+}
+
+// `mindtick export [tag] [range] --format txt -o file`
+func Export(in *Input) error {
+	args, format, outPath := in.Args, in.String("format"), in.String("out")
+
+	// the extension of -o picks the format when --format is missing
+	switch {
+	case format == "" && outPath != "":
+		format = outPath
+	case format == "":
+		format = "txt"
+	}
+	exporter, err := export.Lookup(format)
+	if err != nil {
+		return err
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	filter, err := parseFilter(args)
+	if err != nil {
+		return err
+	}
+	msgs, err := db.Messages(filter)
+	if err != nil {
+		return err
+	}
+	if len(msgs) == 0 {
+		return fmt.Errorf("no messages found with %s", messages.ColorizeStr(strings.Join(args, " "), messages.BrightPurple))
+	}
+
+	report := export.Report{Title: reportTitle(), Messages: msgs}
+	if filter.Range != store.ANYTIME {
+		report.Range = filter.Range.String()
+	}
+
+	if outPath == "" {
+		return exporter.Export(rawStdout(), report)
+	}
+
+	file, err := os.Create(appPath(outPath))
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %v", outPath, err)
+	}
+	if err := exporter.Export(file, report); err != nil {
+		file.Close()
+		return fmt.Errorf("unable to export to %s: %v", outPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to export to %s: %v", outPath, err)
+	}
+	fmt.Fprintf(messages.Stdout, "%d messages exported to %s\n", len(msgs), messages.ColorizeStr(outPath, messages.BrightPurple))
+	return nil
+}
@@ -0,0 +1,178 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ninesl/mindtick/git"
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// hookNames are the hooks `mindtick hook install` writes
+var hookNames = []string{"post-commit", "post-merge"}
+
+// hookMarker is the second line of every hook mindtick wrote
+const hookMarker = "# mindtick hook, remove with `mindtick hook uninstall`"
+
+// chainedSuffix is appended to the name of a hook that was there before mindtick's
+const chainedSuffix = ".pre-mindtick"
+
+// hookScript runs the chained hook, then logs HEAD to the store in dir
+// with `mindtick tag`. Rebases are skipped, their commits were logged already.
+const hookScript = `#!/bin/sh
+%s
+status=0
+chained="$(dirname "$0")/$(basename "$0")%s"
+if [ -x "$chained" ]; then
+	"$chained" "$@"
+	status=$?
+fi
+
+git_dir=$(git rev-parse --git-dir) || exit $status
+if [ -d "$git_dir/rebase-merge" ] || [ -d "$git_dir/rebase-apply" ]; then
+	exit $status
+fi
+command -v mindtick >/dev/null 2>&1 || exit $status
+msg=$(git log -1 --format='%%s (%%h)')
+(cd %s && mindtick %s-- "$msg") >/dev/null 2>&1
+exit $status
+`
+
+// shellQuote quotes s for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hookRepo finds the repository that holds the nearest store and where hooks change
+// directory to before logging, the global store is logged to from the current repository
+func hookRepo() (repo *git.Repo, storeDir string, err error) {
+	dbPath, err := store.FindMindtick()
+	if err != nil {
+		return nil, "", err
+	}
+	dir := filepath.Dir(dbPath)
+	if store.Global {
+		dir = appPath(".")
+	}
+
+	repo, err = git.Find(dir)
+	if errors.Is(err, git.ErrNotRepo) {
+		return nil, "", fmt.Errorf("%s is not inside a git work tree", messages.ColorizeStr(dir, messages.BrightPurple))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read the git repository: %v", err)
+	}
+	storeDir, err = filepath.Rel(repo.Root, dir)
+	if err != nil {
+		return nil, "", err
+	}
+	return repo, storeDir, nil
+}
+
+// isMindtickHook reports whether the hook at path was written by mindtick
+func isMindtickHook(path string) bool {
+	content, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(content), hookMarker)
+}
+
+// `mindtick hook install --tag work`
+func hookInstall(in *Input) error {
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	db.Close()
+
+	tagName := in.String("tag")
+	if tagName == "" {
+		tagName = "work"
+	}
+	def, ok := messages.LookupTag(tagName)
+	if !ok {
+		return unknownTagErr(tagName)
+	}
+
+	repo, storeDir, err := hookRepo()
+	if err != nil {
+		return err
+	}
+	hooksDir, err := repo.HooksDir()
+	if err != nil {
+		return fmt.Errorf("unable to read the git config: %v", err)
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("unable to create %s: %v", hooksDir, err)
+	}
+
+	args := shellQuote(strings.ToLower(def.Name)) + " "
+	if store.Global {
+		args = "-g " + args
+	}
+	script := fmt.Sprintf(hookScript, hookMarker, chainedSuffix, shellQuote(storeDir), args)
+
+	for _, name := range hookNames {
+		path := filepath.Join(hooksDir, name)
+		if _, err := os.Stat(path); err == nil && !isMindtickHook(path) {
+			if _, err := os.Stat(path + chainedSuffix); err == nil {
+				return fmt.Errorf("both %s and %s exist, move one of them out of the way", path, path+chainedSuffix)
+			}
+			if err := os.Rename(path, path+chainedSuffix); err != nil {
+				return fmt.Errorf("unable to keep the existing %s hook: %v", name, err)
+			}
+			fmt.Fprintf(messages.Stdout, "existing %s hook kept as %s, it runs first\n",
+				messages.ColorizeStr(name, messages.BrightPurple), messages.ColorizeStr(name+chainedSuffix, messages.BrightGreen))
+		}
+		if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+			return fmt.Errorf("unable to write the %s hook: %v", name, err)
+		}
+	}
+
+	fmt.Fprintf(messages.Stdout, "%s hooks installed in %s, commits are logged as %s\n",
+		messages.ColorizeStr(strings.Join(hookNames, " and "), messages.BrightPurple), hooksDir, def.Render(" "+def.Name+" "))
+	return nil
+}
+
+// `mindtick hook uninstall`, hooks mindtick didn't write are left alone
+func hookUninstall(*Input) error {
+	repo, _, err := hookRepo()
+	if err != nil { // the store may be gone already, uninstall from the current repository then
+		repo, err = git.Find(appPath("."))
+	}
+	if errors.Is(err, git.ErrNotRepo) {
+		return fmt.Errorf("not inside a git work tree")
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read the git repository: %v", err)
+	}
+	hooksDir, err := repo.HooksDir()
+	if err != nil {
+		return fmt.Errorf("unable to read the git config: %v", err)
+	}
+
+	var removed int
+	for _, name := range hookNames {
+		path := filepath.Join(hooksDir, name)
+		if !isMindtickHook(path) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("unable to remove the %s hook: %v", name, err)
+		}
+		removed++
+		if _, err := os.Stat(path + chainedSuffix); err == nil {
+			if err := os.Rename(path+chainedSuffix, path); err != nil {
+				return fmt.Errorf("unable to restore the %s hook: %v", name, err)
+			}
+			fmt.Fprintf(messages.Stdout, "%s hook restored\n", messages.ColorizeStr(name, messages.BrightPurple))
+		}
+	}
+	if removed == 0 {
+		return fmt.Errorf("no mindtick hooks in %s", hooksDir)
+	}
+	fmt.Fprintf(messages.Stdout, "%s %s\n", messages.ColorizeStr("mindtick hooks removed from", messages.BrightPurple), hooksDir)
+	return nil
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestHooks commits with the git binary, the hooks call a fake mindtick that logs its arguments
+func TestHooks(t *testing.T) {
+	for _, bin := range []string{"git", "sh"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	s := newSession(t)
+	repo, log := s.app.Dir, filepath.Join(t.TempDir(), "log")
+	bin := t.TempDir()
+	fake := "#!/bin/sh\necho \"$(basename \"$PWD\") $*\" >> " + shellQuote(log) + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "mindtick"), []byte(fake), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(env, "t")
+	}
+	for _, env := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(env, "t@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	gitRun := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	readLog := func() string {
+		t.Helper()
+		content, _ := os.ReadFile(log)
+		os.Remove(log)
+		return string(content)
+	}
+
+	gitRun("init", "-q", "-b", "main")
+	existing := "#!/bin/sh\necho \"existing $*\" >> " + shellQuote(log) + "\n"
+	s.file(".git/hooks/post-commit", existing)
+	os.Chmod(filepath.Join(repo, ".git", "hooks", "post-commit"), 0755)
+	s.file("notes/.keep", "")
+	s.app.Dir = filepath.Join(repo, "notes")
+	s.run("new")
+	s.run("hook", "install", "--tag", "fix")
+	if !strings.Contains(s.transcript.String(), "hooks installed") {
+		t.Fatalf("install failed:\n%s", s.transcript.String())
+	}
+
+	s.file("../README.md", "hello\n")
+	gitRun("add", "README.md")
+	gitRun("commit", "-q", "-m", "-first commit")
+	hash := gitRun("rev-parse", "--short", "HEAD")
+	if got, want := readLog(), "existing \nnotes fix -- -first commit ("+hash+")\n"; got != want {
+		t.Errorf("post-commit logged %q, want %q", got, want)
+	}
+
+	gitRun("checkout", "-q", "-b", "feature")
+	s.file("../README.md", "hello again\n")
+	gitRun("commit", "-q", "-am", "second")
+	gitRun("checkout", "-q", "main")
+	readLog()
+	gitRun("merge", "-q", "--no-ff", "-m", "Merge feature", "feature")
+	hash = gitRun("rev-parse", "--short", "HEAD")
+	if got, want := readLog(), "notes fix -- Merge feature ("+hash+")\n"; got != want { // only post-merge runs
+		t.Errorf("merge logged %q, want %q", got, want)
+	}
+
+	s.run("hook", "uninstall")
+	if content, err := os.ReadFile(filepath.Join(repo, ".git", "hooks", "post-commit")); err != nil || string(content) != existing {
+		t.Errorf("the existing hook wasn't restored: %q %v", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".git", "hooks", "post-merge")); !os.IsNotExist(err) {
+		t.Errorf("post-merge is still there: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ninesl/mindtick/export"
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// `mindtick import file.jsonl --format jsonl --create-tags --dry-run`, - reads stdin
+func Import(in *Input) error {
+	createTags, dryRun, format := in.Bool("create-tags"), in.Bool("dry-run"), in.String("format")
+	if len(in.Args) != 1 {
+		return fmt.Errorf("mindtick %s requires one file, %s", messages.ColorizeStr("import", messages.BrightPurple), in.helpMsg())
+	}
+	path := in.Args[0]
+	if format == "" && path == "-" {
+		return fmt.Errorf("%s requires %s, %s", messages.ColorizeStr("mindtick import -", messages.BrightPurple), messages.ColorizeStr("--format", messages.BrightPurple), in.helpMsg())
+	}
+	if format == "" {
+		format = path
+	}
+	importer, err := export.LookupImporter(format)
+	if err != nil {
+		return err
+	}
+
+	var records []export.Record
+	if path == "-" {
+		records, err = importer.Import(stdin)
+	} else {
+		var file *os.File
+		if file, err = os.Open(appPath(path)); err != nil {
+			return fmt.Errorf("unable to open %s: %v", path, err)
+		}
+		records, err = importer.Import(file)
+		file.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("unable to import %s: %v", path, err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("%s has no messages", messages.ColorizeStr(path, messages.BrightPurple))
+	}
+
+	entries := make([]store.Imported, len(records))
+	for i, rec := range records {
+		entries[i] = store.Imported{Timestamp: rec.Timestamp, Tag: rec.Tag, Text: rec.Text,
+			Status: messages.StrToStatus[rec.Status], Priority: messages.StrToPriority[rec.Priority], Private: rec.Private}
+		if rec.Due != nil {
+			entries[i].Due = *rec.Due
+		}
+	}
+
+	// created tags follow the same rules as `mindtick tag add`
+	if createTags {
+		loadStoreTags()
+		for _, rec := range records {
+			if _, ok := messages.LookupTag(rec.Tag); ok {
+				continue
+			}
+			if err := validTagName(rec.Tag); err != nil {
+				return err
+			}
+		}
+	}
+
+	// a dry run never creates the global store
+	open := store.LoadOrCreateMindtick
+	if dryRun {
+		open = store.LoadMindtick
+	}
+	db, err := open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.Import(entries, createTags, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Added) > 0 {
+		slices.SortStableFunc(result.Added, func(a, b messages.Message) int {
+			return a.Timestamp.Compare(b.Timestamp)
+		})
+		messages.RenderMessages(result.Added...)
+		fmt.Fprintln(messages.Stdout)
+	}
+
+	summary := fmt.Sprintf("%d added, %d duplicates skipped", len(result.Added), len(result.Duplicates))
+	if len(result.NewTags) > 0 {
+		summary += ", new tags " + strings.Join(result.NewTags, ", ")
+	}
+	if dryRun {
+		summary = "dry run, nothing was imported: " + summary
+	}
+	fmt.Fprintln(messages.Stdout, messages.ColorizeStr(summary, messages.BrightPurple))
+	return nil
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// TestImport imports each format into a fresh store: unknown tags need
+// --create-tags, and importing the same file again only finds duplicates
+func TestImport(t *testing.T) {
+	files := map[string]string{
+		"csv": "timestamp,tag,text\n2026-03-01 10:00,win,\"shipped, at last\"\n2026-03-02 11:00,deploy,v1.3\n2026-03-02 11:00,deploy,v1.3\n",
+		"json": `[{"timestamp":"2026-03-01T10:00:00Z","tag":"win","text":"shipped, at last"},
+			{"timestamp":"2026-03-02T11:00:00Z","tag":"deploy","text":"v1.3"},
+			{"timestamp":"2026-03-02T11:00:00Z","tag":"Deploy","text":"v1.3"}]`,
+		"jsonl": `{"timestamp":"2026-03-01T10:00:00Z","tag":"win","text":"shipped, at last"}
+{"timestamp":"2026-03-02T11:00:00Z","tag":"deploy","text":"v1.3"}
+{"timestamp":"2026-03-02T11:00:00Z","tag":"DEPLOY","text":"v1.3"}
+`,
+	}
+	for format, content := range files {
+		t.Run(format, func(t *testing.T) {
+			s := newSession(t)
+			s.run("new")
+			s.file("old."+format, content)
+			expect := func(want string, args ...string) {
+				t.Helper()
+				start := s.transcript.Len()
+				s.run(args...)
+				if got := s.transcript.String()[start:]; !strings.Contains(got, want) {
+					t.Errorf("want %q in\n%s", want, got)
+				}
+			}
+
+			expect("unknown tags deploy", "import", "old."+format)
+			expect("dry run, nothing was imported: 2 added, 1 duplicates skipped, new tags deploy", "import", "old."+format, "--create-tags", "--dry-run")
+			expect("2 added, 1 duplicates skipped, new tags deploy", "import", "old."+format, "--create-tags")
+			expect("0 added, 3 duplicates skipped", "import", "old."+format)
+			expect("shipped, at last", "view", "win")
+			expect("v1.3", "view", "deploy")
+			s.file("broken."+format, "id,text\n1,\"no timestamp\"\n")
+			expect("unable to import broken."+format, "import", "broken."+format)
+		})
+	}
+}
+
+// TestImportRoundTrip exports a private, done task with a due date and priority and imports it into a new store
+func TestImportRoundTrip(t *testing.T) {
+	for _, format := range []string{"csv", "json", "jsonl"} {
+		t.Run(format, func(t *testing.T) {
+			a := newSession(t)
+			a.run("new")
+			a.run("task", "ship the release", "--due", "fri", "--prio", "high", "--private")
+			a.run("done", "1")
+			a.run("export", "-o", "backup."+format)
+			want := onlyMessage(t)
+			backup, err := os.ReadFile(filepath.Join(a.app.Dir, "backup."+format))
+			if err != nil {
+				t.Fatalf("%v\n%s", err, a.transcript.String())
+			}
+
+			b := newSession(t)
+			b.run("new")
+			b.file("backup."+format, string(backup))
+			b.run("import", "backup."+format)
+			got := onlyMessage(t)
+			if got.Status != messages.DONE || !got.Due.Equal(want.Due) || got.Priority != messages.HIGH || !got.Private ||
+				!got.Timestamp.Equal(want.Timestamp) || got.Msg != want.Msg {
+				t.Errorf("imported %+v\nexported %+v\n%s", got, want, b.transcript.String())
+			}
+		})
+	}
+}
+
+// onlyMessage returns the one message of the store the last session opened
+func onlyMessage(t *testing.T) messages.Message {
+	t.Helper()
+	db, err := store.LoadMindtick()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	msgs, err := db.Messages(store.Filter{})
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("messages = %v, %v", msgs, err)
+	}
+	return msgs[0]
+}
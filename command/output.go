@@ -0,0 +1,125 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/export"
+	"github.com/ninesl/mindtick/messages"
+)
+
+// output is the --output format of the read commands, "" prints colored text
+var output string
+
+var outputFormats = []string{"json", "jsonl", "tsv"}
+
+// outputCommands are the commands with --output, like "view" or "tag list"
+func outputCommands() []string {
+	var names []string
+	var walk func(cmds []*Cmd, prefix string)
+	walk = func(cmds []*Cmd, prefix string) {
+		for _, cmd := range cmds {
+			if _, ok := cmd.flag("output", false); ok {
+				names = append(names, prefix+cmd.Name)
+			}
+			walk(cmd.Subs, prefix+cmd.Name+" ")
+		}
+	}
+	walk(commands, "")
+	return names
+}
+
+// setOutput sets output to format, one of outputFormats
+func setOutput(format string) error {
+	format = strings.ToLower(format)
+	if !slices.Contains(outputFormats, format) {
+		return fmt.Errorf("unknown output format %s\nvalid formats are %v",
+			messages.ColorizeStr(format, messages.BrightPurple), messages.ColorizeStr(strings.Join(outputFormats, ", "), messages.BrightGreen))
+	}
+	output = format
+	return nil
+}
+
+// writeMessages prints msgs as output records, the same ones `mindtick export` writes
+func writeMessages(msgs []messages.Message) error {
+	exporter, err := export.Lookup(output)
+	if err != nil {
+		return err
+	}
+	return exporter.Export(messages.Stdout, export.Report{Messages: msgs})
+}
+
+// writeRecords prints records that aren't messages, json and jsonl encode the
+// records themselves and tsv writes header and the row of each record
+func writeRecords[T any](records []T, header []string, row func(T) []string) error {
+	switch output {
+	case "json":
+		enc := json.NewEncoder(messages.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "jsonl":
+		enc := json.NewEncoder(messages.Stdout)
+		for _, rec := range records {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	rows := [][]string{}
+	for _, rec := range records {
+		rows = append(rows, row(rec))
+	}
+	return export.WriteTSV(messages.Stdout, header, rows)
+}
+
+// tagRecord is a tag as printed by `mindtick tags --json`
+type tagRecord struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Bg   string `json:"bg"`
+	Fg   string `json:"fg"`
+}
+
+func writeTags(defs []messages.TagDef) error {
+	records := []tagRecord{}
+	for _, def := range defs {
+		records = append(records, tagRecord{ID: int(def.ID), Name: def.Name, Bg: def.Bg, Fg: def.Fg})
+	}
+	return writeRecords(records, []string{"id", "name", "bg", "fg"}, func(rec tagRecord) []string {
+		return []string{fmt.Sprint(rec.ID), rec.Name, rec.Bg, rec.Fg}
+	})
+}
+
+// rangeRecord is a range as printed by `mindtick ranges --json`, a nil bound is unbounded
+type rangeRecord struct {
+	Name  string     `json:"name"`
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+}
+
+func newRangeRecord(name string, start, end time.Time) rangeRecord {
+	rec := rangeRecord{Name: name}
+	if !start.IsZero() {
+		rec.Start = &start
+	}
+	if !end.IsZero() {
+		rec.End = &end
+	}
+	return rec
+}
+
+func writeRanges(records []rangeRecord) error {
+	format := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	return writeRecords(records, []string{"name", "start", "end"}, func(rec rangeRecord) []string {
+		return []string{rec.Name, format(rec.Start), format(rec.End)}
+	})
+}
@@ -0,0 +1,78 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ninesl/mindtick/export"
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// `mindtick report [tag] [range] --client --format md -o file`
+func Report(in *Input) error {
+	args, format, outPath := in.Args, strings.ToLower(in.String("format")), in.String("out")
+
+	// the extension of -o picks the format when --format is missing
+	switch {
+	case format == "" && outPath != "":
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(outPath)), ".")
+	case format == "":
+		format = "txt"
+	}
+	if !slices.Contains(export.ProgressFormats, format) {
+		return fmt.Errorf("unknown report format %s\nvalid formats are %v",
+			messages.ColorizeStr(format, messages.BrightPurple), messages.ColorizeStr(strings.Join(export.ProgressFormats, ", "), messages.BrightGreen))
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	filter, err := parseFilter(args)
+	if err != nil {
+		return err
+	}
+	msgs, err := db.Messages(filter)
+	if err != nil {
+		return err
+	}
+
+	report := export.Report{Title: reportTitle(), Messages: msgs}
+	if filter.Range != store.ANYTIME {
+		report.Range = filter.Range.String()
+	}
+	progress := export.NewProgress(report, in.Bool("client"))
+	if progress.Len() == 0 {
+		what := "messages"
+		if in.Bool("client") {
+			what = "public wins or fixes"
+		}
+		if len(args) > 0 {
+			what += " with " + messages.ColorizeStr(strings.Join(args, " "), messages.BrightPurple)
+		}
+		return fmt.Errorf("no %s to report", what)
+	}
+
+	if outPath == "" {
+		return export.WriteProgress(rawStdout(), format, progress)
+	}
+	file, err := os.Create(appPath(outPath))
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %v", outPath, err)
+	}
+	if err := export.WriteProgress(file, format, progress); err != nil {
+		file.Close()
+		return fmt.Errorf("unable to write %s: %v", outPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to write %s: %v", outPath, err)
+	}
+	fmt.Fprintf(messages.Stdout, "report of %s written to %s\n", progress.Headline(""), messages.ColorizeStr(outPath, messages.BrightPurple))
+	return nil
+}
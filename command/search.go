@@ -0,0 +1,38 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// `mindtick search keywords...`
+func Search(in *Input) error {
+	args := in.Args
+	if len(args) == 0 {
+		return fmt.Errorf("mindtick %s requires keywords, %s", messages.ColorizeStr("search", messages.BrightPurple), in.helpMsg())
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	query := store.SearchQuery(args...)
+	msgs, err := db.Messages(store.Filter{Query: query})
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		return writeMessages(msgs)
+	}
+	if len(msgs) == 0 {
+		return fmt.Errorf("no messages found with %s", messages.ColorizeStr(strings.Join(args, " "), messages.BrightPurple))
+	}
+
+	messages.RenderMessagesWith(messages.RenderOptions{IDs: in.Bool("ids"), Highlight: store.HighlightTerms(query)}, msgs...)
+	return nil
+}
@@ -0,0 +1,148 @@
+package command
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// workdaysSetting is the work week of the last `mindtick standup --workdays`
+const workdaysSetting = "standup_workdays"
+
+var standupFormats = []string{"plain", "slack"}
+
+// standupSection is a heading and its items, items are plain text
+type standupSection struct {
+	heading, note string // note follows the heading, like the day Yesterday was
+	items         []string
+}
+
+// slackEscape escapes the characters slack reads as markup
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// renderStandup writes sections to be pasted, bullets are - in plain text and • in slack
+func renderStandup(sections []standupSection, format string) string {
+	var b strings.Builder
+	for i, section := range sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		heading, bullet, none := section.heading, "-", "none"
+		if format == "slack" {
+			heading, bullet, none = "*"+heading+"*", "•", "_none_"
+		}
+		if section.note != "" {
+			heading += " (" + section.note + ")"
+		}
+		b.WriteString(heading + "\n")
+		if len(section.items) == 0 {
+			fmt.Fprintf(&b, "%s %s\n", bullet, none)
+		}
+		for _, item := range section.items {
+			if format == "slack" {
+				item = slackEscape.Replace(item)
+			}
+			fmt.Fprintf(&b, "%s %s\n", bullet, item)
+		}
+	}
+	return b.String()
+}
+
+// taskSchedule is the due date and priority of an open task in plain text
+func taskSchedule(task messages.Message, now time.Time) string {
+	var parts []string
+	switch {
+	case task.Due.IsZero():
+	case !now.Before(task.Due):
+		parts = append(parts, "overdue since "+messages.RenderDue(task.Due))
+	default:
+		parts = append(parts, "due "+messages.RenderDue(task.Due))
+	}
+	if task.Priority != messages.NORMAL {
+		parts = append(parts, messages.PriorityToStr[task.Priority]+" priority")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// `mindtick standup --format slack --workdays mon-fri`
+func Standup(in *Input) error {
+	format := strings.ToLower(in.String("format"))
+	if format == "" {
+		format = standupFormats[0]
+	}
+	if !slices.Contains(standupFormats, format) {
+		return fmt.Errorf("unknown standup format %s\nvalid formats are %v",
+			messages.ColorizeStr(format, messages.BrightPurple), messages.ColorizeStr(strings.Join(standupFormats, ", "), messages.BrightGreen))
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	workdays := in.String("workdays")
+	if workdays == "" {
+		if workdays, err = db.Setting(workdaysSetting); err != nil {
+			return err
+		}
+	}
+	if workdays == "" {
+		workdays = store.DefaultWorkWeek
+	}
+	week, err := store.ParseWorkWeek(workdays)
+	if err != nil {
+		return err
+	}
+	if in.String("workdays") != "" {
+		if err := db.SetSetting(workdaysSetting, workdays); err != nil {
+			return err
+		}
+	}
+
+	now := messages.Now()
+	lastWorkday := week.LastWorkday(now)
+	yesterday := standupSection{heading: "Yesterday"}
+	if !lastWorkday.Start.Equal(time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, now.Location())) { // skipped back over days off
+		yesterday.note = lastWorkday.Start.Format("Mon Jan 02")
+	}
+	done, err := db.Messages(store.Filter{Tag: messages.ANYTAG, Range: lastWorkday})
+	if err != nil {
+		return err
+	}
+	for _, msg := range done {
+		if msg.Tag == messages.WIN || msg.Tag == messages.FIX || msg.Tag == messages.WORK {
+			yesterday.items = append(yesterday.items, msg.Msg)
+		}
+	}
+
+	today := standupSection{heading: "Today"}
+	tasks, err := db.Messages(store.Filter{Tag: messages.TASK, Statuses: []messages.Status{messages.OPEN}})
+	if err != nil {
+		return err
+	}
+	slices.SortStableFunc(tasks, compareTasks)
+	for _, task := range tasks {
+		today.items = append(today.items, task.Msg+taskSchedule(task, now))
+	}
+
+	// alerts raised since the start of the last workday are still blocking
+	blockers := standupSection{heading: "Blockers"}
+	alerts, err := db.Messages(store.Filter{Tag: messages.ALERT, Range: store.Range{Start: lastWorkday.Start}})
+	if err != nil {
+		return err
+	}
+	for _, alert := range alerts {
+		blockers.items = append(blockers.items, alert.Msg)
+	}
+
+	fmt.Fprint(rawStdout(), renderStandup([]standupSection{yesterday, today, blockers}, format))
+	return nil
+}
@@ -0,0 +1,114 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/export"
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// barWidth is the length of the longest bar of a chart
+const barWidth = 40
+
+// barLen scales n to a bar of a chart whose longest bar is most, never hiding a count above 0
+func barLen(n, most int) int {
+	if n == 0 || most == 0 {
+		return 0
+	}
+	return max(1, n*barWidth/most)
+}
+
+// chartBar is a bar of #s in the accent color, tag bars use messages.TagDef.RenderBar
+func chartBar(n, most int) string {
+	return messages.ColorizeStr(strings.Repeat("#", barLen(n, most)), messages.BrightPurple)
+}
+
+// chartLine prints a row of a chart, the count right aligned to the largest one
+func chartLine(label string, n, most int, bar string) {
+	line := fmt.Sprintf("%s %*d", label, len(fmt.Sprint(most)), n)
+	if n > 0 {
+		line += " " + bar
+	}
+	fmt.Fprintln(messages.Stdout, line)
+}
+
+// `mindtick stats [tag] [range]`
+func Stats(in *Input) error {
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	filter, err := parseFilter(in.Args)
+	if err != nil {
+		return err
+	}
+	stats, err := db.Stats(filter)
+	if err != nil {
+		return err
+	}
+	if stats.Total() == 0 {
+		if len(in.Args) == 0 {
+			return emptyStoreErr(db)
+		}
+		return fmt.Errorf("no messages found with %s", messages.ColorizeStr(strings.Join(in.Args, " "), messages.BrightPurple))
+	}
+
+	now := messages.Now()
+	heading := func(title string) {
+		fmt.Fprintln(messages.Stdout, "\n"+messages.ColorizeStr(title, messages.BrightPurple))
+	}
+	if filter.Range != store.ANYTIME {
+		fmt.Fprintln(messages.Stdout, messages.ColorizeStr(filter.Range.String(), messages.BrightBlack))
+	}
+	current, longest := stats.Streaks(now)
+	fmt.Fprintf(messages.Stdout, "%s on %s, %.1f per day\n", export.Plural(stats.Total(), "message"), export.Plural(len(stats.Days), "day"), stats.PerDay(filter.Range, now))
+	fmt.Fprintf(messages.Stdout, "streak %s, longest %s\n", export.Plural(current, "day"), export.Plural(longest, "day"))
+
+	heading("tags")
+	most := 0
+	for _, tc := range stats.Tags {
+		most = max(most, tc.N)
+	}
+	for _, tc := range stats.Tags {
+		def, _ := messages.TagByID(tc.Tag)
+		chartLine(messages.RenderTag(tc.Tag, false), tc.N, most, def.RenderBar(barLen(tc.N, most)))
+	}
+
+	heading("weekdays")
+	most = 0
+	for _, n := range stats.Weekdays {
+		most = max(most, n)
+	}
+	for i := range 7 {
+		wd := time.Weekday((i + 1) % 7) // monday first
+		chartLine("  "+wd.String()[:3], stats.Weekdays[wd], most, chartBar(stats.Weekdays[wd], most))
+	}
+
+	// from the first to the last hour anything was added
+	heading("hours")
+	first, last, most := -1, 0, 0
+	for hour, n := range stats.Hours {
+		if n > 0 {
+			if first < 0 {
+				first = hour
+			}
+			last = hour
+		}
+		most = max(most, n)
+	}
+	for hour := first; hour <= last; hour++ {
+		chartLine(fmt.Sprintf("  %02d:00", hour), stats.Hours[hour], most, chartBar(stats.Hours[hour], most))
+	}
+
+	heading("busiest days")
+	busiest := stats.Busiest(5)
+	for _, dc := range busiest {
+		chartLine("  "+dc.Day.Format("Mon Jan 02, 2006"), dc.N, busiest[0].N, chartBar(dc.N, busiest[0].N))
+	}
+	return nil
+}
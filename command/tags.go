@@ -0,0 +1,165 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+var tagNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,15}$`)
+
+func unknownTagErr(name string) error {
+	return fmt.Errorf("unknown tag %s\nvalid tags are %v", messages.ColorizeStr(name, messages.BrightPurple), messages.ColorizeStr(strings.Join(messages.TagNames(), ", "), messages.BrightGreen))
+}
+
+// validTagName rejects names that `mindtick <tag>` or `mindtick view <tag> [range]`
+// couldn't tell apart from a command, a range like fri or q3, or a due date like eow
+func validTagName(name string) error {
+	if !tagNameRe.MatchString(name) {
+		return fmt.Errorf("invalid tag name %s, tags start with a letter and may contain letters, digits, - and _ (max 16)", messages.ColorizeStr(name, messages.BrightPurple))
+	}
+	lower := strings.ToLower(name)
+	_, rangeErr := store.ParseRange(lower, messages.Now())
+	_, dueErr := store.ParseDue(lower, messages.Now())
+	if lookupCommand(lower) != nil || slices.Contains(store.RangeNames, lower) || lower == "anytime" || rangeErr == nil || dueErr == nil {
+		return fmt.Errorf("%s can't be used as a tag name, it is already a command or range", messages.ColorizeStr(name, messages.BrightPurple))
+	}
+	return nil
+}
+
+// builtinTagErr refuses to change a builtin tag, what is "renamed" or "removed"
+func builtinTagErr(def messages.TagDef, what string) error {
+	return fmt.Errorf("%s is a builtin tag and can't be %s", def.Render(" "+def.Name+" "), what)
+}
+
+// colorFlag is the value of a color flag, def when it wasn't given
+func colorFlag(in *Input, flag string, def string) (string, error) {
+	value := strings.ToLower(in.String(flag))
+	if value == "" {
+		return def, nil
+	}
+	if !messages.IsColorName(value) {
+		return "", fmt.Errorf("unknown color %s\nvalid colors are %v", messages.ColorizeStr(value, messages.BrightPurple), messages.ColorizeStr(strings.Join(messages.ColorNames, ", "), messages.BrightGreen))
+	}
+	return value, nil
+}
+
+// `mindtick tag add name --bg color --fg color`
+func tagAdd(in *Input) error {
+	bg, err := colorFlag(in, "bg", "bright-black")
+	if err != nil {
+		return err
+	}
+	fg, err := colorFlag(in, "fg", "white")
+	if err != nil {
+		return err
+	}
+	if len(in.Args) != 1 {
+		return fmt.Errorf("%s requires a tag name, %s", messages.ColorizeStr(in.Path, messages.BrightPurple), in.helpMsg())
+	}
+	if err := validTagName(in.Args[0]); err != nil {
+		return err
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	def, err := db.AddTag(messages.TagDef{Name: in.Args[0], Bg: bg, Fg: fg})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(messages.Stdout, "%s %s\n", def.Render(" "+def.Name+" "), messages.ColorizeStr("added", messages.BrightPurple))
+	return nil
+}
+
+// `mindtick tag rename name new-name`
+func tagRename(in *Input) error {
+	if len(in.Args) != 2 {
+		return fmt.Errorf("%s requires a tag and its new name, %s", messages.ColorizeStr(in.Path, messages.BrightPurple), in.helpMsg())
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	def, ok := messages.LookupTag(in.Args[0])
+	if !ok {
+		return unknownTagErr(in.Args[0])
+	}
+	if messages.IsBuiltin(def.ID) {
+		return builtinTagErr(def, "renamed")
+	}
+	if err := validTagName(in.Args[1]); err != nil {
+		return err
+	}
+
+	if err := db.RenameTag(def.ID, in.Args[1]); err != nil {
+		return err
+	}
+	renamed, _ := messages.TagByID(def.ID)
+	fmt.Fprintf(messages.Stdout, "%s -> %s\n", def.Render(" "+def.Name+" "), renamed.Render(" "+renamed.Name+" "))
+	return nil
+}
+
+// `mindtick tag remove name [--reassign tag | --purge]`
+func tagRemove(in *Input) error {
+	purge, reassignName := in.Bool("purge"), in.String("reassign")
+	if len(in.Args) != 1 {
+		return fmt.Errorf("%s requires a tag name, %s", messages.ColorizeStr(in.Path, messages.BrightPurple), in.helpMsg())
+	}
+	if purge && reassignName != "" {
+		return fmt.Errorf("use either %s or %s, %s", messages.ColorizeStr("--reassign", messages.BrightPurple), messages.ColorizeStr("--purge", messages.BrightPurple), in.helpMsg())
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	def, ok := messages.LookupTag(in.Args[0])
+	if !ok {
+		return unknownTagErr(in.Args[0])
+	}
+	if messages.IsBuiltin(def.ID) {
+		return builtinTagErr(def, "removed")
+	}
+
+	reassign := messages.ANYTAG
+	if reassignName != "" {
+		to, ok := messages.LookupTag(reassignName)
+		if !ok {
+			return unknownTagErr(reassignName)
+		}
+		if to.ID == def.ID {
+			return fmt.Errorf("can't reassign messages of %s to itself", messages.ColorizeStr(def.Name, messages.BrightPurple))
+		}
+		reassign = to.ID
+	}
+
+	// messages are never dropped silently, the user has to pick what happens to them
+	used, err := db.TagUsage(def.ID)
+	if err != nil {
+		return err
+	}
+	if used > 0 && !purge && reassign == messages.ANYTAG {
+		return fmt.Errorf("%d messages use %s\nmove them with %s or delete them with %s",
+			used, def.Render(" "+def.Name+" "),
+			messages.ColorizeStr("--reassign tag", messages.BrightGreen), messages.ColorizeStr("--purge", messages.BrightGreen))
+	}
+
+	if err := db.RemoveTag(def.ID, reassign); err != nil {
+		return err
+	}
+	fmt.Fprintf(messages.Stdout, "%s %s\n", def.Render(" "+def.Name+" "), messages.ColorizeStr(fmt.Sprintf("removed, %d messages affected", used), messages.BrightPurple))
+	return nil
+}
@@ -0,0 +1,66 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+func TestValidTagName(t *testing.T) {
+	for _, name := range []string{"deploy", "Review_2", "bug-fix", "q3x"} {
+		if err := validTagName(name); err != nil {
+			t.Errorf("validTagName(%q): %v", name, err)
+		}
+	}
+	// commands, ranges and due dates would be read as something else
+	for _, name := range []string{"", "2fix", "a b", "abcdefghijklmnopq", "view", "Tags", "today", "anytime",
+		"fri", "Friday", "q3", "tomorrow", "eow", "eom", "week"} {
+		if err := validTagName(name); err == nil {
+			t.Errorf("validTagName(%q) expected an error", name)
+		}
+	}
+}
+
+// TestTagCommands adds, renames and removes tags, builtin tags can't be renamed or removed
+func TestTagCommands(t *testing.T) {
+	s := newSession(t)
+	s.run("new")
+	// last prints the output of the command that just ran
+	last := func() string {
+		out := s.transcript.String()
+		return out[strings.LastIndex(out, "$ mindtick"):]
+	}
+	tagNames := func() []string {
+		loadStoreTags()
+		return messages.TagNames()
+	}
+	expect := func(want string, args ...string) {
+		t.Helper()
+		s.run(args...)
+		if got := last(); !strings.Contains(got, want) {
+			t.Errorf("want %q in\n%s", want, got)
+		}
+	}
+
+	expect("added", "tag", "add", "deploy", "--bg", "blue")
+	expect("can't be used as a tag name", "tag", "add", "fri")
+	expect("already exists", "tag", "add", "DEPLOY")
+	expect("unknown color", "tag", "add", "review", "--bg", "mauve")
+
+	expect("release", "tag", "rename", "deploy", "release")
+	expect("can't be used as a tag name", "tag", "rename", "release", "q3")
+	expect("unknown tag", "tag", "rename", "deploy", "ship")
+	for _, name := range []string{"win", "task", "ALERT"} {
+		expect("builtin tag and can't be renamed", "tag", "rename", name, "renamed")
+		expect("builtin tag and can't be removed", "tag", "remove", name, "--purge")
+	}
+
+	s.run("release", "v1.4")
+	expect("1 messages use", "tag", "remove", "release")
+	expect("removed, 1 messages affected", "tag", "remove", "release", "--reassign", "note")
+	if names := tagNames(); strings.Contains(strings.Join(names, " "), "release") || len(names) != len(messages.BuiltinTags) {
+		t.Errorf("tags after remove = %q", names)
+	}
+	expect("v1.4", "view", "note")
+}
@@ -0,0 +1,268 @@
+package command
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+	"github.com/ninesl/mindtick/store"
+)
+
+// setStatus is `mindtick done|cancel|reopen <id>...`
+func setStatus(in *Input, status messages.Status) error {
+	if len(in.Args) < 1 {
+		return fmt.Errorf("mindtick %s requires a task id, %s", messages.ColorizeStr(in.Name, messages.BrightPurple), in.helpMsg())
+	}
+	var ids []int
+	for _, arg := range in.Args {
+		id, err := parseID(arg)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// every id is checked before any task changes
+	var (
+		tasks   []messages.Message
+		changed []int
+	)
+	for _, id := range ids {
+		task, err := db.Message(id)
+		if err != nil {
+			return err
+		}
+		if task.Tag != messages.TASK {
+			return fmt.Errorf("%s\nonly tasks can be marked %s", messages.RenderMsg(task, false), messages.StatusToStr[status])
+		}
+		tasks = append(tasks, task)
+		if task.Status != status {
+			changed = append(changed, id)
+		}
+	}
+	if len(changed) > 0 {
+		if err := db.SetStatus(changed, status, messages.Now()); err != nil {
+			return err
+		}
+	}
+
+	opts := messages.RenderOptions{Status: true}
+	for _, before := range tasks {
+		if before.Status == status {
+			fmt.Fprintln(messages.Stdout, messages.RenderMsgWith(opts, before, false))
+			continue
+		}
+		after := before
+		after.Status = status
+		fmt.Fprintln(messages.Stdout, messages.RenderMsgWith(opts, before, false))
+		fmt.Fprintln(messages.Stdout, messages.RenderMsgWith(opts, after, false))
+	}
+	return nil
+}
+
+func Done(in *Input) error   { return setStatus(in, messages.DONE) }
+func Cancel(in *Input) error { return setStatus(in, messages.CANCELLED) }
+func Reopen(in *Input) error { return setStatus(in, messages.OPEN) }
+
+// taskOptions applies the --due and --prio flags of `mindtick task`, due dates are relative to now
+func taskOptions(msg *messages.Message, dueExpr, prioName string, now time.Time) error {
+	if dueExpr == "" && prioName == "" {
+		return nil
+	}
+	if msg.Tag != messages.TASK {
+		return fmt.Errorf("only tasks have %s and %s, %s", messages.ColorizeStr("--due", messages.BrightPurple), messages.ColorizeStr("--prio", messages.BrightPurple), useHelpMsg)
+	}
+	if dueExpr != "" {
+		due, err := store.ParseDue(dueExpr, now)
+		if err != nil {
+			return err
+		}
+		msg.Due = due
+	}
+	if prioName != "" {
+		prio, ok := messages.StrToPriority[strings.ToLower(prioName)]
+		if !ok {
+			return fmt.Errorf("unknown priority %s, use %s", messages.ColorizeStr(prioName, messages.BrightPurple), messages.ColorizeStr("low, normal or high", messages.BrightGreen))
+		}
+		msg.Priority = prio
+	}
+	return nil
+}
+
+// renderDue colors a due date red once it is overdue and yellow on the day it is due
+func renderDue(due, now time.Time) string {
+	c := messages.BrightBlack
+	switch {
+	case !now.Before(due):
+		c = messages.BrightRed
+	case due.Sub(now) < 24*time.Hour:
+		c = messages.BrightYellow
+	}
+	return messages.ColorizeStr("due "+messages.RenderDue(due), c)
+}
+
+// renderSchedule is the due date and priority shown after a new task
+func renderSchedule(msg messages.Message, now time.Time) string {
+	var parts []string
+	if !msg.Due.IsZero() {
+		parts = append(parts, renderDue(msg.Due, now))
+	}
+	if msg.Priority != messages.NORMAL {
+		parts = append(parts, renderPriority(msg.Priority)+" "+messages.ColorizeStr(messages.PriorityToStr[msg.Priority], messages.BrightBlack))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "  " + strings.Join(parts, "  ")
+}
+
+func renderPriority(prio messages.Priority) string {
+	switch prio {
+	case messages.HIGH:
+		return messages.ColorizeStr("!", messages.Bold, messages.BrightRed)
+	case messages.LOW:
+		return messages.ColorizeStr("-", messages.BrightBlack)
+	}
+	return " "
+}
+
+// compareTasks orders tasks by due date, tasks without one last,
+// then by priority, then oldest first
+func compareTasks(a, b messages.Message) int {
+	switch {
+	case a.Due.IsZero() != b.Due.IsZero():
+		if a.Due.IsZero() {
+			return 1
+		}
+		return -1
+	case !a.Due.Equal(b.Due):
+		return a.Due.Compare(b.Due)
+	case a.Priority != b.Priority:
+		return cmp.Compare(b.Priority, a.Priority)
+	}
+	return a.Timestamp.Compare(b.Timestamp)
+}
+
+// `mindtick todo` lists open tasks by due date and priority with how long they have been open
+func Todo(*Input) error {
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tasks, err := db.Messages(store.Filter{Tag: messages.TASK, Statuses: []messages.Status{messages.OPEN}})
+	if err != nil {
+		return err
+	}
+	slices.SortStableFunc(tasks, compareTasks)
+	if output != "" {
+		return writeMessages(tasks)
+	}
+	if len(tasks) == 0 {
+		return fmt.Errorf("no open tasks, add one with %s", messages.ColorizeStr(`mindtick task "your task"`, messages.BrightGreen))
+	}
+
+	now := messages.Now()
+	var (
+		idWidth, dueWidth int
+		dues              = map[int]string{}
+	)
+	for _, task := range tasks {
+		idWidth = max(idWidth, len(strconv.Itoa(task.ID)))
+		if !task.Due.IsZero() {
+			dues[task.ID] = renderDue(task.Due, now)
+			dueWidth = max(dueWidth, messages.VisibleWidth(dues[task.ID]))
+		}
+	}
+	for _, task := range tasks {
+		due := messages.PadRight(dues[task.ID], dueWidth)
+		if dueWidth > 0 {
+			due += " "
+		}
+		fmt.Fprintf(messages.Stdout, "%s %s %s %s%s\n",
+			messages.ColorizeStr(fmt.Sprintf("%*d", idWidth, task.ID), messages.Dim),
+			messages.ColorizeStr(fmt.Sprintf("%4s", messages.RenderAge(task.Timestamp, now)), messages.BrightBlack),
+			renderPriority(task.Priority),
+			due,
+			task.Msg)
+	}
+	return nil
+}
+
+// snoozeSetting is when the overdue banner shows up again, RFC 3339
+const snoozeSetting = "overdue_snoozed_until"
+
+// bannerless are the commands the overdue banner isn't shown before, they don't work with messages
+var bannerless = []string{"snooze", "help", "version", "new", "delete", "tags", "ranges", "themes"}
+
+// overdueBanner warns about overdue tasks before a command runs, it stays
+// quiet without a store and goes to stderr so piped output stays clean
+func overdueBanner(now time.Time) {
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	if until, _ := db.Setting(snoozeSetting); until != "" {
+		if t, err := time.Parse(time.RFC3339, until); err == nil && now.Before(t) {
+			return
+		}
+	}
+	count, err := db.Overdue(now)
+	if err != nil || count == 0 {
+		return
+	}
+
+	tasks := "tasks are"
+	if count == 1 {
+		tasks = "task is"
+	}
+	fmt.Fprintf(messages.Stderr, "%s see %s or %s\n",
+		messages.ColorizeStr(fmt.Sprintf(" %d %s overdue ", count, tasks), messages.Bold, messages.RedBg, messages.White),
+		messages.ColorizeStr("mindtick todo", messages.BrightGreen),
+		messages.ColorizeStr("mindtick snooze", messages.BrightGreen))
+}
+
+// `mindtick snooze [until]` hides the overdue banner until the due expression
+// passes, today by default, `mindtick snooze off` shows it again
+func Snooze(in *Input) error {
+	db, err := store.LoadMindtick()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	expr := strings.Join(in.Args, " ")
+	if expr == "off" {
+		if err := db.SetSetting(snoozeSetting, ""); err != nil {
+			return err
+		}
+		fmt.Fprintln(messages.Stdout, messages.ColorizeStr("overdue banner unsnoozed", messages.BrightPurple))
+		return nil
+	}
+	if expr == "" {
+		expr = "today"
+	}
+
+	until, err := store.ParseDue(expr, messages.Now())
+	if err != nil {
+		return err
+	}
+	if err := db.SetSetting(snoozeSetting, until.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	fmt.Fprintf(messages.Stdout, "%s %s\n", messages.ColorizeStr("overdue banner snoozed until", messages.BrightPurple), messages.ColorizeStr(messages.RenderDue(until), messages.BrightGreen))
+	return nil
+}
@@ -0,0 +1,141 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Release is a version of a changelog, the zero Version is Unreleased
+type Release struct {
+	Version  string
+	Date     time.Time
+	Sections []Section
+}
+
+// Section groups the entries of a release, like Added or Fixed
+type Section struct {
+	Name    string
+	Entries []string
+}
+
+// SectionOrder are the sections of https://keepachangelog.com in the order they are written,
+// other sections come after them
+var SectionOrder = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+
+const keepachangelogHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// linkRef is a markdown link reference definition, like the compare links at the end of a changelog
+var linkRef = regexp.MustCompile(`^\[[^\]]+\]:\s`)
+
+func (r Release) heading() string {
+	if r.Version == "" {
+		return "## [Unreleased]"
+	}
+	return fmt.Sprintf("## [%s] - %s", r.Version, r.Date.Format(time.DateOnly))
+}
+
+// render writes the entries escaped like the md exporter, an entry can't start a heading or a link
+func (r Release) render() string {
+	var b strings.Builder
+	b.WriteString(r.heading() + "\n")
+	for _, section := range r.Sections {
+		fmt.Fprintf(&b, "\n### %s\n", section.Name)
+		for _, entry := range section.Entries {
+			fmt.Fprintf(&b, "- %s\n", markdownEscape.Replace(entry))
+		}
+	}
+	return b.String()
+}
+
+// releaseVersion is the version of a "## [1.2.0] - 2026-03-01" heading, "" for Unreleased.
+// A leading v doesn't count, v1.2 and 1.2 are the same release.
+func releaseVersion(heading string) string {
+	version := strings.TrimSpace(strings.TrimPrefix(heading, "##"))
+	if start, end := strings.Index(version, "["), strings.Index(version, "]"); start >= 0 && end > start {
+		version = version[start+1 : end]
+	} else if before, _, ok := strings.Cut(version, " - "); ok {
+		version = before
+	}
+	version = strings.ToLower(strings.TrimSpace(version))
+	if version == "unreleased" {
+		return ""
+	}
+	return strings.TrimPrefix(version, "v")
+}
+
+// KeepAChangelog renders releases, newest first, into existing, the contents of a CHANGELOG.md.
+// Releases of existing with the same version are replaced, everything else is kept as written.
+func KeepAChangelog(existing string, releases []Release) string {
+	lines := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+	if existing == "" {
+		lines = nil
+	}
+
+	// link references at the end stay at the end
+	footer := len(lines)
+	for footer > 0 && (linkRef.MatchString(lines[footer-1]) || strings.TrimSpace(lines[footer-1]) == "") {
+		footer--
+	}
+	for footer < len(lines) && strings.TrimSpace(lines[footer]) == "" {
+		footer++
+	}
+
+	var (
+		preamble []string
+		kept     []string // releases of existing that aren't replaced
+		replaced bool     // the release being read is replaced
+	)
+	generated := map[string]bool{}
+	for _, r := range releases {
+		generated[strings.TrimPrefix(strings.ToLower(r.Version), "v")] = true
+	}
+	inRelease := false
+	for _, line := range lines[:footer] {
+		if strings.HasPrefix(line, "## ") {
+			inRelease = true
+			replaced = generated[releaseVersion(line)]
+		}
+		switch {
+		case !inRelease:
+			preamble = append(preamble, line)
+		case !replaced:
+			kept = append(kept, line)
+		}
+	}
+
+	var b strings.Builder
+	if len(preamble) == 0 {
+		b.WriteString(keepachangelogHeader)
+	} else {
+		b.WriteString(strings.TrimRight(strings.Join(preamble, "\n"), "\n") + "\n")
+	}
+	for _, r := range releases {
+		b.WriteString("\n" + r.render())
+	}
+	if len(kept) > 0 {
+		b.WriteString("\n" + strings.TrimRight(strings.Join(kept, "\n"), "\n") + "\n")
+	}
+	if footer < len(lines) {
+		b.WriteString("\n" + strings.Join(lines[footer:], "\n") + "\n")
+	}
+	return b.String()
+}
+
+// SortSections orders sections by SectionOrder, keeping the order of the others
+func SortSections(sections []Section) {
+	rank := func(s Section) int {
+		if i := slices.Index(SectionOrder, s.Name); i >= 0 {
+			return i
+		}
+		return len(SectionOrder)
+	}
+	slices.SortStableFunc(sections, func(a, b Section) int { return rank(a) - rank(b) })
+}
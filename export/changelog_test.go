@@ -0,0 +1,27 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeepAChangelogEscapes(t *testing.T) {
+	releases := []Release{{Version: "1.2", Date: time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC), Sections: []Section{
+		{Name: "Added", Entries: []string{"*bold* _it_ [link](x) `code`", "## 1.0 - not a release", "a <b> tag"}},
+	}}}
+	out := KeepAChangelog("", releases)
+	for _, want := range []string{
+		"- \\*bold\\* \\_it\\_ \\[link\\](x) \\`code\\`\n",
+		"- \\#\\# 1.0 - not a release\n",
+		"- a &lt;b&gt; tag\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	// the entries are read back as entries, not as another release
+	if again := KeepAChangelog(out, releases); again != out {
+		t.Errorf("rewriting the changelog changed it:\n%s", again)
+	}
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type csvExporter struct{}
+
+func (csvExporter) Export(w io.Writer, r Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write(RecordHeader)
+	for _, msg := range r.Messages {
+		cw.Write(NewRecord(msg).Fields())
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// tsvExporter writes tab separated values for shell pipelines
+type tsvExporter struct{}
+
+func (tsvExporter) Export(w io.Writer, r Report) error {
+	rows := [][]string{}
+	for _, msg := range r.Messages {
+		rows = append(rows, NewRecord(msg).Fields())
+	}
+	return WriteTSV(w, RecordHeader, rows)
+}
+
+// WriteTSV writes a header row and rows as tab separated values, tabs and
+// line breaks inside a value become spaces since tsv can't quote them
+func WriteTSV(w io.Writer, header []string, rows [][]string) error {
+	clean := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+	for _, row := range append([][]string{header}, rows...) {
+		fields := make([]string, len(row))
+		for i, field := range row {
+			fields[i] = clean.Replace(field)
+		}
+		if _, err := io.WriteString(w, strings.Join(fields, "\t")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type jsonExporter struct{}
+
+func (jsonExporter) Export(w io.Writer, r Report) error {
+	records := []Record{}
+	for _, msg := range r.Messages {
+		records = append(records, NewRecord(msg))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+type jsonlExporter struct{}
+
+func (jsonlExporter) Export(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	for _, msg := range r.Messages {
+		if err := enc.Encode(NewRecord(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Package export writes messages to files for people who don't use mindtick.
+package export
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Report is what gets exported
+type Report struct {
+	Title    string
+	Range    string             // human readable range of the messages, empty for anytime
+	Messages []messages.Message // sorted by timestamp
+}
+
+// Exporter writes a Report in one file format
+type Exporter interface {
+	Export(w io.Writer, r Report) error
+}
+
+// Exporters by format name, also the file extension of the format
+var Exporters = map[string]Exporter{
+	"csv":   csvExporter{},
+	"json":  jsonExporter{},
+	"jsonl": jsonlExporter{},
+	"md":    markdownExporter{},
+	"html":  htmlExporter{},
+	"pdf":   pdfExporter{},
+	"tsv":   tsvExporter{},
+	"txt":   textExporter{},
+}
+
+// Formats are the keys of Exporters, sorted
+func Formats() []string {
+	var formats []string
+	for format := range Exporters {
+		formats = append(formats, format)
+	}
+	slices.Sort(formats)
+	return formats
+}
+
+// Lookup returns the exporter for format, which may also be a file name like report.md
+func Lookup(format string) (Exporter, error) {
+	format = strings.ToLower(format)
+	if i := strings.LastIndex(format, "."); i >= 0 {
+		format = format[i+1:]
+	}
+	exporter, ok := Exporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %s\nvalid formats are %v",
+			messages.ColorizeStr(format, messages.BrightPurple), messages.ColorizeStr(strings.Join(Formats(), ", "), messages.BrightGreen))
+	}
+	return exporter, nil
+}
+
+// Record is the plain form of a message used by the data formats
+type Record struct {
+	ID        int           `json:"id"`
+	Timestamp time.Time     `json:"timestamp"`
+	Tag       string        `json:"tag"`
+	Text      string        `json:"text"`
+	Status    string        `json:"status"`
+	Due       *time.Time    `json:"due,omitempty"`
+	Priority  string        `json:"priority"`
+	Git       *messages.Git `json:"git,omitempty"` // only in json and jsonl
+	Private   bool          `json:"private,omitempty"`
+}
+
+// RecordHeader names the columns of Record.Fields
+var RecordHeader = []string{"id", "timestamp", "tag", "text", "status", "due", "priority", "private"}
+
+// Fields are the columns of the csv and tsv formats, times are RFC 3339
+func (rec Record) Fields() []string {
+	var due string
+	if rec.Due != nil {
+		due = rec.Due.Format(time.RFC3339)
+	}
+	return []string{strconv.Itoa(rec.ID), rec.Timestamp.Format(time.RFC3339), rec.Tag, rec.Text, rec.Status, due, rec.Priority, strconv.FormatBool(rec.Private)}
+}
+
+func tagName(tag messages.Tag) string {
+	if def, ok := messages.TagByID(tag); ok {
+		return strings.ToLower(def.Name)
+	}
+	return ""
+}
+
+func NewRecord(msg messages.Message) Record {
+	rec := Record{
+		ID:        msg.ID,
+		Timestamp: msg.Timestamp,
+		Tag:       tagName(msg.Tag),
+		Text:      msg.Msg,
+		Status:    messages.StatusToStr[msg.Status],
+		Priority:  messages.PriorityToStr[msg.Priority],
+	}
+	if !msg.Due.IsZero() {
+		rec.Due = &msg.Due
+	}
+	if msg.Git.Repo != "" {
+		rec.Git = &msg.Git
+	}
+	rec.Private = msg.Private
+	return rec
+}
+
+// byDay groups messages by calendar day like messages.RenderMessages
+func byDay(msgs []messages.Message) [][]messages.Message {
+	var days [][]messages.Message
+	for i, msg := range msgs {
+		if i == 0 || !sameDay(msgs[i-1].Timestamp, msg.Timestamp) {
+			days = append(days, nil)
+		}
+		days[len(days)-1] = append(days[len(days)-1], msg)
+	}
+	return days
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+const (
+	dayLayout  = "Jan 02, 2006"
+	timeLayout = "03:04 PM"
+)
@@ -0,0 +1,240 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+var (
+	day1 = time.Date(2026, 3, 12, 9, 0, 0, 0, time.Local)
+	day2 = time.Date(2026, 3, 13, 16, 30, 0, 0, time.Local)
+
+	testReport = Report{
+		Title: `Acme & "Co" <dev>`,
+		Range: "Mar 12, 2026 to Mar 13, 2026",
+		Messages: []messages.Message{
+			{ID: 1, Timestamp: day1, Tag: messages.WIN, Msg: `shipped <b>csv</b> & "tsv", finally`},
+			{ID: 2, Timestamp: day1.Add(90 * time.Minute), Tag: messages.FIX, Msg: "tab\there\nand a newline"},
+			{ID: 3, Timestamp: day2, Tag: messages.TASK, Msg: "*bold* _it_ [link](x) #1 | pipe", Due: day2.Add(24 * time.Hour), Priority: messages.HIGH},
+		},
+	}
+)
+
+func TestExporters(t *testing.T) {
+	texts := func(records []Record) []string {
+		var texts []string
+		for _, rec := range records {
+			texts = append(texts, rec.Tag+": "+rec.Text)
+		}
+		return texts
+	}
+	wantTexts := []string{
+		`win: shipped <b>csv</b> & "tsv", finally`,
+		"fix: tab\there\nand a newline",
+		"task: *bold* _it_ [link](x) #1 | pipe",
+	}
+
+	tests := map[string]func(t *testing.T, out string){
+		"csv": func(t *testing.T, out string) {
+			rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 4 || strings.Join(rows[0], ",") != strings.Join(RecordHeader, ",") {
+				t.Fatalf("rows = %q", rows)
+			}
+			var records []Record
+			for _, row := range rows[1:] {
+				records = append(records, Record{Tag: row[2], Text: row[3]})
+			}
+			if got := texts(records); strings.Join(got, "|") != strings.Join(wantTexts, "|") {
+				t.Errorf("texts = %q", got)
+			}
+			if rows[3][5] != day2.Add(24*time.Hour).Format(time.RFC3339) || rows[3][6] != "high" {
+				t.Errorf("due and priority = %q", rows[3][5:])
+			}
+		},
+		"tsv": func(t *testing.T, out string) {
+			lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+			if len(lines) != 4 {
+				t.Fatalf("lines = %q", lines)
+			}
+			if fields := strings.Split(lines[2], "\t"); len(fields) != len(RecordHeader) || fields[3] != "tab here and a newline" {
+				t.Errorf("tabs and newlines should become spaces: %q", fields)
+			}
+			if fields := strings.Split(lines[1], "\t"); fields[3] != `shipped <b>csv</b> & "tsv", finally` {
+				t.Errorf("text = %q", fields[3])
+			}
+		},
+		"json": func(t *testing.T, out string) {
+			var records []Record
+			if err := json.Unmarshal([]byte(out), &records); err != nil {
+				t.Fatal(err)
+			}
+			if got := texts(records); strings.Join(got, "|") != strings.Join(wantTexts, "|") {
+				t.Errorf("texts = %q", got)
+			}
+		},
+		"jsonl": func(t *testing.T, out string) {
+			var records []Record
+			for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+				var rec Record
+				if err := json.Unmarshal([]byte(line), &rec); err != nil {
+					t.Fatalf("%q: %v", line, err)
+				}
+				records = append(records, rec)
+			}
+			if got := texts(records); strings.Join(got, "|") != strings.Join(wantTexts, "|") {
+				t.Errorf("texts = %q", got)
+			}
+		},
+		"md": func(t *testing.T, out string) {
+			for _, want := range []string{
+				`# Acme & "Co" &lt;dev&gt;`,
+				"## Mar 12, 2026",
+				`- **win** 09:00 AM shipped &lt;b&gt;csv&lt;/b&gt; & "tsv", finally`,
+				`- **task** 04:30 PM \*bold\* \_it\_ \[link\](x) \#1 \| pipe`,
+			} {
+				if !strings.Contains(out, want) {
+					t.Errorf("missing %q in\n%s", want, out)
+				}
+			}
+		},
+		"html": func(t *testing.T, out string) {
+			for _, want := range []string{
+				"<title>Acme &amp; &#34;Co&#34; &lt;dev&gt;</title>",
+				"<h2>Mar 13, 2026</h2>",
+				`shipped &lt;b&gt;csv&lt;/b&gt; &amp; &#34;tsv&#34;, finally</li>`,
+			} {
+				if !strings.Contains(out, want) {
+					t.Errorf("missing %q in\n%s", want, out)
+				}
+			}
+			if strings.Contains(out, "<b>") {
+				t.Errorf("message html wasn't escaped:\n%s", out)
+			}
+		},
+		"txt": func(t *testing.T, out string) {
+			want := `Acme & "Co" <dev>
+Mar 12, 2026 to Mar 13, 2026
+
+[ Mar 12, 2026 ]
+  win 09:00 AM     shipped <b>csv</b> & "tsv", finally
+  fix 10:30 AM     tab	here
+and a newline
+
+[ Mar 13, 2026 ]
+ task 04:30 PM     *bold* _it_ [link](x) #1 | pipe
+`
+			if out != want {
+				t.Errorf("got\n%s\nwant\n%s", out, want)
+			}
+		},
+		"pdf": func(t *testing.T, out string) {
+			checkPDF(t, out)
+			// parentheses in text are escaped, < and & need no escaping in a string literal
+			if !strings.Contains(out, `/Title (Acme & "Co" <dev>)`) || !strings.Contains(out, `\(x\)`) {
+				t.Error("pdf strings aren't escaped")
+			}
+		},
+	}
+
+	for _, format := range Formats() {
+		check, ok := tests[format]
+		if !ok {
+			t.Errorf("no test for the %s exporter", format)
+			continue
+		}
+		t.Run(format, func(t *testing.T) {
+			exporter, err := Lookup("report." + format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			if err := exporter.Export(&out, testReport); err != nil {
+				t.Fatal(err)
+			}
+			check(t, out.String())
+		})
+	}
+}
+
+// TestPDFLongMessage splits a message taller than a page instead of drawing it over the footer
+func TestPDFLongMessage(t *testing.T) {
+	report := Report{Title: "long", Messages: []messages.Message{
+		{ID: 1, Timestamp: day1, Tag: messages.NOTE, Msg: strings.Repeat("a long message ", 2000)},
+		{ID: 2, Timestamp: day1.Add(time.Hour), Tag: messages.WIN, Msg: "after it"},
+	}}
+	exporter, _ := Lookup("pdf")
+	var out bytes.Buffer
+	if err := exporter.Export(&out, report); err != nil {
+		t.Fatal(err)
+	}
+	checkPDF(t, out.String())
+
+	// the footer is the only text below the margin
+	for _, m := range regexp.MustCompile(`(-?[\d.]+) Td (\(.*?\)) Tj`).FindAllStringSubmatch(out.String(), -1) {
+		y, _ := strconv.ParseFloat(m[1], 64)
+		if y < pdfMargin && !regexp.MustCompile(`^\(\d+ / \d+\)$`).MatchString(m[2]) {
+			t.Errorf("%s drawn at y %.2f, below the margin", m[2], y)
+			break
+		}
+	}
+	if pages := strings.Count(out.String(), "/Type /Page "); pages < 3 {
+		t.Errorf("%d pages, the message should be split across pages", pages)
+	}
+}
+
+// checkPDF checks the structure readers rely on: the header, an xref table
+// pointing at every object, the trailer and stream lengths
+func checkPDF(t *testing.T, out string) {
+	if !strings.HasPrefix(out, "%PDF-1.4\n") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatalf("missing header or %%%%EOF:\n%.40q ... %q", out, out[max(len(out)-20, 0):])
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindStringSubmatch(out)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(out[xref:], "xref\n") {
+		t.Fatalf("startxref %d doesn't point at the xref table: %.20q", xref, out[xref:])
+	}
+	var first, size int
+	if _, err := fmt.Sscanf(out[xref:], "xref\n%d %d\n", &first, &size); err != nil || first != 0 {
+		t.Fatalf("xref subsection: %v", err)
+	}
+	entries := strings.Split(out[xref:], "\n")[2 : 2+size]
+	if entries[0] != "0000000000 65535 f " {
+		t.Errorf("object 0 = %q", entries[0])
+	}
+	for n, entry := range entries[1:] {
+		offset, err := strconv.Atoi(entry[:10])
+		if err != nil || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("xref entry %d = %q", n+1, entry)
+		}
+		if obj := fmt.Sprintf("%d 0 obj\n", n+1); !strings.HasPrefix(out[offset:], obj) {
+			t.Errorf("xref offset %d of object %d points at %.20q", offset, n+1, out[offset:])
+		}
+	}
+	if trailer := fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R", size); !strings.Contains(out[xref:], trailer) {
+		t.Errorf("trailer should have /Size %d", size)
+	}
+
+	streams := regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`)
+	for _, loc := range streams.FindAllStringSubmatchIndex(out, -1) {
+		length, _ := strconv.Atoi(out[loc[2]:loc[3]])
+		if !strings.HasPrefix(out[loc[1]+length:], "endstream") {
+			t.Errorf("stream at %d isn't %d bytes long", loc[1], length)
+		}
+	}
+}
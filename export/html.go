@@ -0,0 +1,88 @@
+package export
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// cssColors are the messages.ColorNames as css colors
+var cssColors = map[string]string{
+	"black":         "#000000",
+	"red":           "#cd3131",
+	"green":         "#0dbc79",
+	"yellow":        "#e5e510",
+	"blue":          "#2472c8",
+	"purple":        "#bc3fbc",
+	"cyan":          "#11a8cd",
+	"white":         "#e5e5e5",
+	"bright-black":  "#666666",
+	"bright-red":    "#f14c4c",
+	"bright-green":  "#23d18b",
+	"bright-yellow": "#f5f543",
+	"bright-blue":   "#3b8eea",
+	"bright-purple": "#d670d6",
+	"bright-cyan":   "#29b8db",
+	"bright-white":  "#ffffff",
+}
+
+type htmlMessage struct {
+	Tag, Bg, Fg, Time, Text string
+}
+
+type htmlDay struct {
+	Date     string
+	Messages []htmlMessage
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+h2 { font-size: 1.1rem; margin-top: 2rem; border-bottom: 1px solid #ddd; }
+ul { list-style: none; padding: 0; }
+li { margin: .4rem 0; }
+.tag { display: inline-block; min-width: 3.5rem; padding: 0 .4rem; border-radius: .25rem; font-weight: bold; text-align: center; font-size: .85rem; }
+.time { color: #888; margin: 0 .5rem; font-variant-numeric: tabular-nums; }
+.range { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Range}}<p class="range">{{.Range}}</p>{{end}}
+{{range .Days}}<h2>{{.Date}}</h2>
+<ul>
+{{range .Messages}}<li><span class="tag" style="background: {{.Bg}}; color: {{.Fg}}">{{.Tag}}</span><span class="time">{{.Time}}</span>{{.Text}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+type htmlExporter struct{}
+
+func (htmlExporter) Export(w io.Writer, r Report) error {
+	data := struct {
+		Title, Range string
+		Days         []htmlDay
+	}{Title: r.Title, Range: r.Range}
+
+	for _, day := range byDay(r.Messages) {
+		hd := htmlDay{Date: day[0].Timestamp.Format(dayLayout)}
+		for _, msg := range day {
+			def, _ := messages.TagByID(msg.Tag)
+			hd.Messages = append(hd.Messages, htmlMessage{
+				Tag:  def.Name,
+				Bg:   cssColors[def.Bg],
+				Fg:   cssColors[def.Fg],
+				Time: msg.Timestamp.Format(timeLayout),
+				Text: msg.Msg,
+			})
+		}
+		data.Days = append(data.Days, hd)
+	}
+	return htmlTemplate.Execute(w, data)
+}
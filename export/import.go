@@ -0,0 +1,181 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Importer reads back the records an Exporter wrote
+type Importer interface {
+	Import(r io.Reader) ([]Record, error)
+}
+
+// Importers by format name, the data formats of Exporters
+var Importers = map[string]Importer{
+	"csv":   csvExporter{},
+	"json":  jsonExporter{},
+	"jsonl": jsonlExporter{},
+}
+
+// LookupImporter returns the importer for format, which may also be a file name like old.csv
+func LookupImporter(format string) (Importer, error) {
+	format = strings.ToLower(format)
+	if i := strings.LastIndex(format, "."); i >= 0 {
+		format = format[i+1:]
+	}
+	importer, ok := Importers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %s\nvalid formats are %v",
+			messages.ColorizeStr(format, messages.BrightPurple), messages.ColorizeStr("csv, json, jsonl", messages.BrightGreen))
+	}
+	return importer, nil
+}
+
+// timestamp layouts accepted on import, spreadsheets rarely write RFC 3339
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+	"01/02/2006",
+}
+
+// parseTimestamp reads s in time.Local, or in its own zone converted to time.Local
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t.In(time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown timestamp %s", messages.ColorizeStr(s, messages.BrightPurple))
+}
+
+// validate checks the fields import can't do without, an empty status or priority is open and normal
+func (rec Record) validate() error {
+	switch {
+	case rec.Timestamp.IsZero():
+		return fmt.Errorf("missing timestamp")
+	case rec.Tag == "":
+		return fmt.Errorf("missing tag")
+	case rec.Text == "":
+		return fmt.Errorf("missing text")
+	}
+	if _, ok := messages.StrToStatus[rec.Status]; !ok && rec.Status != "" {
+		return fmt.Errorf("unknown status %s", messages.ColorizeStr(rec.Status, messages.BrightPurple))
+	}
+	if _, ok := messages.StrToPriority[rec.Priority]; !ok && rec.Priority != "" {
+		return fmt.Errorf("unknown priority %s", messages.ColorizeStr(rec.Priority, messages.BrightPurple))
+	}
+	return nil
+}
+
+// inLocal converts the times of rec to time.Local
+func (rec *Record) inLocal() {
+	rec.Timestamp = rec.Timestamp.In(time.Local)
+	if rec.Due != nil {
+		due := rec.Due.In(time.Local)
+		rec.Due = &due
+	}
+}
+
+// Import reads a csv with a header row, the columns are matched by name
+// and only timestamp, tag and text are required, private is written by mindtick but not by spreadsheets
+func (csvExporter) Import(r io.Reader) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read csv: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"timestamp", "tag", "text"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv is missing the %s column", messages.ColorizeStr(name, messages.BrightPurple))
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var records []Record
+	for line, row := range rows[1:] {
+		rec := Record{
+			Tag:      strings.TrimSpace(field(row, "tag")),
+			Text:     field(row, "text"),
+			Status:   strings.ToLower(strings.TrimSpace(field(row, "status"))),
+			Priority: strings.ToLower(strings.TrimSpace(field(row, "priority"))),
+		}
+		rec.ID, _ = strconv.Atoi(field(row, "id"))
+		rec.Private, _ = strconv.ParseBool(strings.TrimSpace(field(row, "private")))
+		if rec.Timestamp, err = parseTimestamp(field(row, "timestamp")); err != nil {
+			return nil, fmt.Errorf("csv row %d: %v", line+2, err)
+		}
+		if due := field(row, "due"); strings.TrimSpace(due) != "" {
+			t, err := parseTimestamp(due)
+			if err != nil {
+				return nil, fmt.Errorf("csv row %d: due: %v", line+2, err)
+			}
+			rec.Due = &t
+		}
+		if err := rec.validate(); err != nil {
+			return nil, fmt.Errorf("csv row %d: %v", line+2, err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (jsonExporter) Import(r io.Reader) ([]Record, error) {
+	var records []Record
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("unable to read json: %v", err)
+	}
+	for i, rec := range records {
+		if err := rec.validate(); err != nil {
+			return nil, fmt.Errorf("json record %d: %v", i+1, err)
+		}
+		records[i].inLocal()
+	}
+	return records, nil
+}
+
+func (jsonlExporter) Import(r io.Reader) ([]Record, error) {
+	var records []Record
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var rec Record
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jsonl record %d: %v", line, err)
+		}
+		if err := rec.validate(); err != nil {
+			return nil, fmt.Errorf("jsonl record %d: %v", line, err)
+		}
+		rec.inLocal()
+		records = append(records, rec)
+	}
+}
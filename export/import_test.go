@@ -0,0 +1,87 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+func TestImporters(t *testing.T) {
+	tests := []struct {
+		format, input string
+		want          []string // tag: text of the records
+		err           string   // in the error of bad input
+	}{
+		{"csv", "id,timestamp,tag,text\n1,2026-03-12T09:00:00Z,win,\"shipped, finally\"\n,2026-03-12 10:00,Deploy,v1.3\n",
+			[]string{"win: shipped, finally", "Deploy: v1.3"}, ""},
+		{"csv", "Text , TAG,Timestamp\nno id column,note,03/12/2026\n", []string{"note: no id column"}, ""},
+		{"csv", "", nil, ""},
+		{"csv", "timestamp,text\n2026-03-12,no tag column\n", nil, "missing the tag column"},
+		{"csv", "timestamp,tag,text\n2026-03-12,win,ok\nyesterday,win,bad time\n", nil, "csv row 3: unknown timestamp"},
+		{"csv", "timestamp,tag,text\n2026-03-12,,no tag\n", nil, "csv row 2: missing tag"},
+		{"csv", "timestamp,tag,text\n2026-03-12,win\n", nil, "csv row 2: missing text"},
+		{"csv", "timestamp,tag,text\n2026-03-12,win,\"unterminated\n", nil, "unable to read csv"},
+		{"csv", "timestamp,tag,text,status\n2026-03-12,task,ok,finished\n", nil, "csv row 2: unknown status finished"},
+		{"csv", "timestamp,tag,text,due\n2026-03-12,task,ok,someday\n", nil, "csv row 2: due: unknown timestamp"},
+
+		{"json", `[{"timestamp":"2026-03-12T09:00:00+09:00","tag":"win","text":"a <b> & \"c\""}]`, []string{`win: a <b> & "c"`}, ""},
+		{"json", `[]`, nil, ""},
+		{"json", `[{"timestamp":"2026-03-12T09:00:00Z","tag":"win"}]`, nil, "json record 1: missing text"},
+		{"json", `[{"tag":"win","text":"when"}]`, nil, "json record 1: missing timestamp"},
+		{"json", `[{"timestamp":"2026-03-12T09:00:00Z","tag":"task","text":"ok","priority":"urgent"}]`, nil, "json record 1: unknown priority urgent"},
+		{"json", `{"tag":"win"}`, nil, "unable to read json"},
+
+		{"jsonl", "{\"timestamp\":\"2026-03-12T09:00:00Z\",\"tag\":\"fix\",\"text\":\"one\"}\n{\"timestamp\":\"2026-03-12T10:00:00Z\",\"tag\":\"custom\",\"text\":\"two\"}\n",
+			[]string{"fix: one", "custom: two"}, ""},
+		{"jsonl", "{\"timestamp\":\"2026-03-12T09:00:00Z\",\"tag\":\"fix\",\"text\":\"one\"}\nnot json\n", nil, "jsonl record 2"},
+		{"jsonl", "{\"timestamp\":\"2026-03-12T09:00:00Z\",\"text\":\"no tag\"}\n", nil, "jsonl record 1: missing tag"},
+	}
+	for _, tt := range tests {
+		importer, err := LookupImporter(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := importer.Import(strings.NewReader(tt.input))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(messages.StripANSI(err.Error()), tt.err) {
+				t.Errorf("%s %q: error %v, want %q", tt.format, tt.input, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.format, tt.input, err)
+			continue
+		}
+		var got []string
+		for _, rec := range records {
+			got = append(got, rec.Tag+": "+rec.Text)
+			if rec.Timestamp.Location() != time.Local {
+				t.Errorf("%s %q: timestamp %v isn't in time.Local", tt.format, tt.input, rec.Timestamp)
+			}
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s %q = %q, want %q", tt.format, tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := LookupImporter("notes.pdf"); err == nil {
+		t.Error("pdf can't be imported")
+	}
+	if _, err := LookupImporter("backup.JSONL"); err != nil {
+		t.Errorf("LookupImporter(backup.JSONL): %v", err)
+	}
+}
+
+// TestImportZones keeps the instant of timestamps written in another zone
+func TestImportZones(t *testing.T) {
+	importer, _ := LookupImporter("jsonl")
+	records, err := importer.Import(strings.NewReader(`{"timestamp":"2026-03-12T09:00:00+09:00","tag":"win","text":"tokyo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC); !records[0].Timestamp.Equal(want) || records[0].Timestamp.Location() != time.Local {
+		t.Errorf("timestamp = %v, want %v in time.Local", records[0].Timestamp, want)
+	}
+}
@@ -0,0 +1,300 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// The pdf exporter writes PDF 1.4 by hand using the standard Helvetica fonts,
+// which every reader ships, so nothing is embedded and nothing is downloaded.
+
+const (
+	pdfPageW  = 595.28 // A4 in points
+	pdfPageH  = 841.89
+	pdfMargin = 56.0
+
+	pdfRegular = "F1"
+	pdfBold    = "F2"
+)
+
+// helveticaWidths are the widths of ' ' through '~' in 1/1000 em, from the Helvetica AFM
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth estimates the width of s in points, bold is about 10% wider
+func textWidth(s string, font string, size float64) float64 {
+	var units int
+	for _, r := range s {
+		if r >= ' ' && r <= '~' {
+			units += helveticaWidths[r-' ']
+		} else {
+			units += 556
+		}
+	}
+	width := float64(units) * size / 1000
+	if font == pdfBold {
+		width *= 1.1
+	}
+	return width
+}
+
+// winAnsi are the runes outside of latin-1 that WinAnsiEncoding can show
+var winAnsi = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfString encodes s as a WinAnsiEncoding string literal, runes it can't show become '?'
+func pdfString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, r := range s {
+		var b byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			b = byte(r)
+		case r >= ' ' && r <= '~', r >= 0xa0 && r <= 0xff:
+			b = byte(r)
+		case winAnsi[r] != 0:
+			b = winAnsi[r]
+		default:
+			b = '?'
+		}
+		sb.WriteByte(b)
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// wrap breaks s into lines no wider than width
+func wrap(s string, font string, size, width float64) []string {
+	var (
+		lines []string
+		line  string
+	)
+	for _, word := range strings.Fields(s) {
+		// words wider than a line are cut wherever they overflow
+		for textWidth(word, font, size) > width {
+			cut := len(word)
+			for cut > 1 && textWidth(word[:cut], font, size) > width {
+				_, n := utf8.DecodeLastRuneInString(word[:cut])
+				cut -= n
+			}
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, word[:cut])
+			word = word[cut:]
+		}
+
+		switch {
+		case line == "":
+			line = word
+		case textWidth(line+" "+word, font, size) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+type rgb [3]float64
+
+// cssRGB converts a #rrggbb css color
+func cssRGB(hex string) rgb {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return rgb{}
+	}
+	return rgb{float64(v>>16&0xff) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255}
+}
+
+var (
+	pdfBlack = rgb{0.13, 0.13, 0.13}
+	pdfGrey  = rgb{0.5, 0.5, 0.5}
+	pdfLine  = rgb{0.85, 0.85, 0.85}
+)
+
+// pdfDoc lays out pages top to bottom, y is the baseline of the next line
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+func (d *pdfDoc) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pdfPageH - pdfMargin
+}
+
+// need starts a new page unless height more points fit on this one
+func (d *pdfDoc) need(height float64) {
+	if d.page == nil || d.y-height < pdfMargin {
+		d.newPage()
+	}
+}
+
+func (d *pdfDoc) text(x, y float64, font string, size float64, c rgb, s string) {
+	fmt.Fprintf(d.page, "BT %.3f %.3f %.3f rg /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", c[0], c[1], c[2], font, size, x, y, pdfString(s))
+}
+
+func (d *pdfDoc) rect(x, y, w, h float64, c rgb) {
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", c[0], c[1], c[2], x, y, w, h)
+}
+
+// badge draws a tag in its colors and returns its width
+func (d *pdfDoc) badge(x, y float64, tag messages.Tag) float64 {
+	def, ok := messages.TagByID(tag)
+	if !ok {
+		def = messages.TagDef{Name: "?", Bg: "bright-black", Fg: "white"}
+	}
+	const size = 8.0
+	width := textWidth(def.Name, pdfBold, size) + 8
+	d.rect(x, y-3, width, size+5, cssRGB(cssColors[def.Bg]))
+	d.text(x+4, y, pdfBold, size, cssRGB(cssColors[def.Fg]), def.Name)
+	return width
+}
+
+func (d *pdfDoc) titlePage(r Report) {
+	d.newPage()
+	y := pdfPageH * 0.62
+	d.text(pdfMargin, y, pdfBold, 28, pdfBlack, r.Title)
+	d.text(pdfMargin, y-32, pdfRegular, 14, pdfGrey, "Progress report")
+
+	dates := r.Range
+	if dates == "" && len(r.Messages) > 0 {
+		first, last := r.Messages[0].Timestamp, r.Messages[len(r.Messages)-1].Timestamp
+		dates = first.Format(dayLayout) + " to " + last.Format(dayLayout)
+	}
+	d.text(pdfMargin, y-52, pdfRegular, 12, pdfGrey, dates)
+	d.rect(pdfMargin, y-68, pdfPageW-2*pdfMargin, 0.8, pdfLine)
+
+	// how many messages of each tag, in tag order
+	counts := map[messages.Tag]int{}
+	for _, msg := range r.Messages {
+		counts[msg.Tag]++
+	}
+	x, rowY := pdfMargin, y-92
+	for _, def := range messages.TagDefs() {
+		if counts[def.ID] == 0 {
+			continue
+		}
+		label := strconv.Itoa(counts[def.ID])
+		if x+80 > pdfPageW-pdfMargin {
+			x, rowY = pdfMargin, rowY-22
+		}
+		x += d.badge(x, rowY, def.ID) + 4
+		d.text(x, rowY, pdfRegular, 10, pdfBlack, label)
+		x += textWidth(label, pdfRegular, 10) + 16
+	}
+}
+
+func (d *pdfDoc) days(r Report) {
+	const (
+		size     = 10.0
+		leading  = 14.0
+		textX    = pdfMargin + 110
+		maxWidth = pdfPageW - pdfMargin - textX
+	)
+
+	d.newPage()
+	for _, day := range byDay(r.Messages) {
+		d.need(40 + leading)
+		if d.y != pdfPageH-pdfMargin {
+			d.y -= 12
+		}
+		d.text(pdfMargin, d.y, pdfBold, 13, pdfBlack, day[0].Timestamp.Format(dayLayout))
+		d.rect(pdfMargin, d.y-6, pdfPageW-2*pdfMargin, 0.6, pdfLine)
+		d.y -= 24
+
+		for _, msg := range day {
+			// a message starts on the next page when it doesn't fit, one taller than a page is split
+			lines := wrap(msg.Msg, pdfRegular, size, maxWidth)
+			d.need(min(float64(len(lines))*leading, pdfPageH-2*pdfMargin))
+			d.badge(pdfMargin, d.y, msg.Tag)
+			d.text(pdfMargin+56, d.y, pdfRegular, 9, pdfGrey, msg.Timestamp.Format(timeLayout))
+			for _, line := range lines {
+				d.need(leading)
+				d.text(textX, d.y, pdfRegular, size, pdfBlack, line)
+				d.y -= leading
+			}
+			d.y -= 4
+		}
+	}
+}
+
+// write serializes the pages, numbering them in the footer
+func (d *pdfDoc) write(w io.Writer, title string) error {
+	var (
+		out     bytes.Buffer
+		offsets []int
+	)
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 page tree, 3 and 4 fonts, 5 info, then a page and its contents for each page
+	const firstPage = 6
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title %s /Producer (mindtick) >>", pdfString(title)))
+
+	for i, page := range d.pages {
+		d.page = page
+		footer := fmt.Sprintf("%d / %d", i+1, len(d.pages))
+		d.text(pdfPageW-pdfMargin-textWidth(footer, pdfRegular, 8), pdfMargin/2, pdfRegular, 8, pdfGrey, footer)
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageW, pdfPageH, pdfRegular, pdfBold, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// pdfExporter writes a paginated report: a title page with the date range
+// and a count per tag, then a section per day with each message's tag badge
+type pdfExporter struct{}
+
+func (pdfExporter) Export(w io.Writer, r Report) error {
+	var d pdfDoc
+	d.titlePage(r)
+	d.days(r)
+	return d.write(w, r.Title)
+}
@@ -0,0 +1,237 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Progress summarizes a Report for people who don't read every message,
+// a headline of counts per tag and the entries grouped by week
+type Progress struct {
+	Title, Range string
+	Counts       []Count
+	Weeks        []Week
+	Tagged       bool // entries are listed with their tag, client reports only list wins
+}
+
+// Count is how many messages of Tag a Progress covers
+type Count struct {
+	Tag messages.Tag
+	N   int
+}
+
+// Week holds the entries from Monday Start to the next Monday
+type Week struct {
+	Start   time.Time
+	Entries []messages.Message
+}
+
+// ProgressFormats are the formats WriteProgress writes
+var ProgressFormats = []string{"md", "html", "txt"}
+
+// weekStart is midnight of the Monday on or before t
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
+
+// NewProgress summarizes the messages of r. Client reports are curated for
+// people outside the project: only wins and fixes are counted, only wins are
+// listed and private messages are left out. Cancelled tasks never count.
+func NewProgress(r Report, client bool) Progress {
+	p := Progress{Title: r.Title, Range: r.Range, Tagged: !client}
+	counts := map[messages.Tag]int{}
+	for _, msg := range r.Messages {
+		if msg.Status == messages.CANCELLED || client && (msg.Private || msg.Tag != messages.WIN && msg.Tag != messages.FIX) {
+			continue
+		}
+		counts[msg.Tag]++
+		if client && msg.Tag != messages.WIN {
+			continue
+		}
+		if start := weekStart(msg.Timestamp); len(p.Weeks) == 0 || !p.Weeks[len(p.Weeks)-1].Start.Equal(start) {
+			p.Weeks = append(p.Weeks, Week{Start: start})
+		}
+		week := &p.Weeks[len(p.Weeks)-1]
+		week.Entries = append(week.Entries, msg)
+	}
+
+	// wins and fixes lead the headline, the other tags follow in the order they were created
+	rank := func(tag messages.Tag) int {
+		switch tag {
+		case messages.WIN:
+			return -2
+		case messages.FIX:
+			return -1
+		}
+		return int(tag)
+	}
+	for tag, n := range counts {
+		p.Counts = append(p.Counts, Count{Tag: tag, N: n})
+	}
+	slices.SortFunc(p.Counts, func(a, b Count) int { return rank(a.Tag) - rank(b.Tag) })
+	return p
+}
+
+// Len is the number of messages counted in the headline
+func (p Progress) Len() int {
+	var n int
+	for _, c := range p.Counts {
+		n += c.N
+	}
+	return n
+}
+
+// Plural is "1 win", "3 wins" or "2 fixes"
+func Plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	for _, suffix := range []string{"s", "x", "z", "ch", "sh"} {
+		if strings.HasSuffix(noun, suffix) {
+			return fmt.Sprintf("%d %ses", n, noun)
+		}
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// Headline is the counts as a sentence, like "12 wins and 5 fixes", each count wrapped in emphasis
+func (p Progress) Headline(emphasis string) string {
+	var parts []string
+	for _, c := range p.Counts {
+		parts = append(parts, emphasis+Plural(c.N, tagName(c.Tag))+emphasis)
+	}
+	if len(parts) < 2 {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+func (w Week) heading() string {
+	return "Week of " + w.Start.Format(dayLayout)
+}
+
+// WriteProgress writes p in one of ProgressFormats
+func WriteProgress(w io.Writer, format string, p Progress) error {
+	switch format {
+	case "md":
+		return progressMarkdown(w, p)
+	case "html":
+		return progressHTML(w, p)
+	case "txt":
+		return progressText(w, p)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+func progressText(w io.Writer, p Progress) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, p.Title)
+	if p.Range != "" {
+		fmt.Fprintln(bw, p.Range)
+	}
+	fmt.Fprintf(bw, "\n%s\n", p.Headline(""))
+	for _, week := range p.Weeks {
+		fmt.Fprintf(bw, "\n%s\n", week.heading())
+		for _, msg := range week.Entries {
+			text := msg.Msg
+			if p.Tagged {
+				text = tagName(msg.Tag) + ": " + text
+			}
+			if p.Tagged && msg.Private {
+				text += " (private)"
+			}
+			fmt.Fprintf(bw, "- %s\n", text)
+		}
+	}
+	return bw.Flush()
+}
+
+func progressMarkdown(w io.Writer, p Progress) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n", markdownEscape.Replace(p.Title))
+	if p.Range != "" {
+		fmt.Fprintf(bw, "\n_%s_\n", p.Range)
+	}
+
+	fmt.Fprintf(bw, "\n%s\n", p.Headline("**"))
+
+	for _, week := range p.Weeks {
+		fmt.Fprintf(bw, "\n## %s\n\n", week.heading())
+		for _, msg := range week.Entries {
+			text := markdownEscape.Replace(msg.Msg)
+			if p.Tagged {
+				text = "**" + tagName(msg.Tag) + "** " + text
+			}
+			if p.Tagged && msg.Private {
+				text += " _(private)_"
+			}
+			fmt.Fprintf(bw, "- %s\n", text)
+		}
+	}
+	return bw.Flush()
+}
+
+type htmlEntry struct {
+	Tag, Bg, Fg, Text string
+	Private           bool
+}
+
+type htmlWeek struct {
+	Heading string
+	Entries []htmlEntry
+}
+
+var progressTemplate = template.Must(template.New("progress").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+h2 { font-size: 1.1rem; margin-top: 2rem; border-bottom: 1px solid #ddd; }
+li { margin: .4rem 0; }
+.headline { font-size: 1.25rem; }
+.tag { display: inline-block; min-width: 3.5rem; padding: 0 .4rem; margin-right: .5rem; border-radius: .25rem; font-weight: bold; text-align: center; font-size: .85rem; }
+.range, .private { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Range}}<p class="range">{{.Range}}</p>{{end}}
+<p class="headline">{{.Headline}}</p>
+{{range .Weeks}}<h2>{{.Heading}}</h2>
+<ul>
+{{range .Entries}}<li>{{if .Tag}}<span class="tag" style="background: {{.Bg}}; color: {{.Fg}}">{{.Tag}}</span>{{end}}{{.Text}}{{if .Private}} <span class="private">(private)</span>{{end}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+func progressHTML(w io.Writer, p Progress) error {
+	data := struct {
+		Title, Range, Headline string
+		Weeks                  []htmlWeek
+	}{Title: p.Title, Range: p.Range, Headline: p.Headline("")}
+
+	for _, week := range p.Weeks {
+		hw := htmlWeek{Heading: week.heading()}
+		for _, msg := range week.Entries {
+			entry := htmlEntry{Text: msg.Msg}
+			if p.Tagged {
+				def, _ := messages.TagByID(msg.Tag)
+				entry.Tag, entry.Bg, entry.Fg, entry.Private = def.Name, cssColors[def.Bg], cssColors[def.Fg], msg.Private
+			}
+			hw.Entries = append(hw.Entries, entry)
+		}
+		data.Weeks = append(data.Weeks, hw)
+	}
+	return progressTemplate.Execute(w, data)
+}
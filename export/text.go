@@ -0,0 +1,54 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// textExporter is `mindtick view` without colors
+type textExporter struct{}
+
+func (textExporter) Export(w io.Writer, r Report) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, r.Title)
+	if r.Range != "" {
+		fmt.Fprintln(bw, r.Range)
+	}
+
+	width := 5
+	for _, msg := range r.Messages {
+		width = max(width, len(tagName(msg.Tag)))
+	}
+	for _, day := range byDay(r.Messages) {
+		fmt.Fprintf(bw, "\n[ %s ]\n", day[0].Timestamp.Format(dayLayout))
+		for _, msg := range day {
+			fmt.Fprintf(bw, "%*s %8s     %s\n", width, tagName(msg.Tag), msg.Timestamp.Format(timeLayout), msg.Msg)
+		}
+	}
+	return bw.Flush()
+}
+
+type markdownExporter struct{}
+
+// markdownEscape keeps message text from turning into markdown syntax
+var markdownEscape = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;", "#", `\#`, "|", `\|`,
+)
+
+func (markdownExporter) Export(w io.Writer, r Report) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n", markdownEscape.Replace(r.Title))
+	if r.Range != "" {
+		fmt.Fprintf(bw, "\n_%s_\n", r.Range)
+	}
+
+	for _, day := range byDay(r.Messages) {
+		fmt.Fprintf(bw, "\n## %s\n\n", day[0].Timestamp.Format(dayLayout))
+		for _, msg := range day {
+			fmt.Fprintf(bw, "- **%s** %s %s\n", tagName(msg.Tag), msg.Timestamp.Format(timeLayout), markdownEscape.Replace(msg.Msg))
+		}
+	}
+	return bw.Flush()
+}
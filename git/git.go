@@ -0,0 +1,178 @@
+// Package git reads the state of a git work tree straight from its .git directory,
+// without running git or touching the network
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepo is returned by Find outside of a git work tree
+var ErrNotRepo = errors.New("not inside a git work tree")
+
+// Repo is a git work tree
+type Repo struct {
+	Root      string // the work tree
+	GitDir    string // HEAD and the index, .git or .git/worktrees/name for a linked worktree
+	CommonDir string // refs, hooks and config shared by every worktree, the same as GitDir outside linked worktrees
+}
+
+// Find finds the work tree dir is in, walking up to the root of the filesystem
+func Find(dir string) (*Repo, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return &Repo{Root: dir, GitDir: dotGit, CommonDir: dotGit}, nil
+			}
+			return linkedWorktree(dir, dotGit)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNotRepo
+		}
+		dir = parent
+	}
+}
+
+// linkedWorktree follows the .git file of a worktree or submodule, "gitdir: path"
+func linkedWorktree(root, dotGit string) (*Repo, error) {
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return nil, err
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+	if !ok {
+		return nil, fmt.Errorf("%s is not a gitdir link", dotGit)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(root, gitDir)
+	}
+
+	repo := &Repo{Root: root, GitDir: gitDir, CommonDir: gitDir}
+	// only linked worktrees have a commondir, submodules have a git dir of their own
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		repo.CommonDir = strings.TrimSpace(string(common))
+		if !filepath.IsAbs(repo.CommonDir) {
+			repo.CommonDir = filepath.Join(gitDir, repo.CommonDir)
+		}
+	}
+	return repo, nil
+}
+
+// Name is the name of the work tree directory
+func (r *Repo) Name() string {
+	return filepath.Base(r.Root)
+}
+
+// Head returns the checked out branch, empty on a detached HEAD,
+// and the commit HEAD points to, empty before the first commit
+func (r *Repo) Head() (branch, commit string, err error) {
+	ref := "HEAD"
+	for range 5 { // symbolic refs can point to symbolic refs
+		target, err := r.readRef(ref)
+		if err != nil {
+			return "", "", err
+		}
+		name, symbolic := strings.CutPrefix(target, "ref: ")
+		if !symbolic {
+			return branch, target, nil
+		}
+		ref = name
+		branch = strings.TrimPrefix(name, "refs/heads/")
+	}
+	return "", "", fmt.Errorf("too many levels of symbolic refs in %s", r.GitDir)
+}
+
+// readRef reads a loose ref, or one from packed-refs. A missing ref is empty.
+func (r *Repo) readRef(name string) (string, error) {
+	dir := r.CommonDir
+	if name == "HEAD" {
+		dir = r.GitDir
+	}
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err == nil {
+		return strings.TrimSpace(string(content)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	return r.packedRef(name)
+}
+
+// packedRef looks name up in packed-refs, lines are "<hash> <name>"
+func (r *Repo) packedRef(name string) (string, error) {
+	file, err := os.Open(filepath.Join(r.CommonDir, "packed-refs"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, ref, ok := strings.Cut(scanner.Text(), " ")
+		if ok && ref == name {
+			return hash, nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// HooksDir is where git runs hooks from, core.hooksPath or the hooks directory shared by every worktree
+func (r *Repo) HooksDir() (string, error) {
+	path, err := r.config("core", "hookspath")
+	if err != nil || path == "" {
+		return filepath.Join(r.CommonDir, "hooks"), err
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, rest)
+	}
+	if !filepath.IsAbs(path) { // relative to where hooks run, the top of the work tree
+		path = filepath.Join(r.Root, path)
+	}
+	return path, nil
+}
+
+// config reads a value of the repository config, "" when it isn't set.
+// Only the config of the repository is read, includes and subsections are ignored.
+func (r *Repo) config(section, key string) (string, error) {
+	file, err := os.Open(filepath.Join(r.CommonDir, "config"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var current, value string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			current = strings.ToLower(strings.Trim(line, "[] \t"))
+			continue
+		}
+		name, val, _ := strings.Cut(line, "=")
+		if current == section && strings.EqualFold(strings.TrimSpace(name), key) {
+			value = strings.Trim(strings.TrimSpace(val), `"`) // the last one wins, like git
+		}
+	}
+	return value, scanner.Err()
+}
@@ -0,0 +1,186 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// repo creates a work tree with one commit on main, using the git binary
+func repo(t *testing.T) (dir string, run func(args ...string) string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir = t.TempDir()
+	run = func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com", "GIT_CONFIG_GLOBAL=/dev/null")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-q", "-b", "main")
+	write(t, filepath.Join(dir, "README.md"), "hello\n")
+	if err := os.MkdirAll(filepath.Join(dir, "cmd", "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write(t, filepath.Join(dir, "cmd", "app", "main.go"), "package main\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "first")
+	return dir, run
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func find(t *testing.T, dir string) *Repo {
+	t.Helper()
+	r, err := Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func checkHead(t *testing.T, r *Repo, branch, commit string) {
+	t.Helper()
+	gotBranch, gotCommit, err := r.Head()
+	if err != nil || gotBranch != branch || gotCommit != commit {
+		t.Errorf("Head() = %q, %q, %v, want %q, %q", gotBranch, gotCommit, err, branch, commit)
+	}
+}
+
+func checkDirty(t *testing.T, r *Repo, want bool) {
+	t.Helper()
+	if got, err := r.Dirty(); err != nil || got != want {
+		t.Errorf("Dirty() = %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir, _ := repo(t)
+	r := find(t, filepath.Join(dir, "cmd", "app"))
+	if r.Root != dir || r.Name() != filepath.Base(dir) {
+		t.Errorf("Find from a subdirectory = %+v", r)
+	}
+	if _, err := Find(t.TempDir()); !errors.Is(err, ErrNotRepo) {
+		t.Errorf("Find outside a work tree = %v, want ErrNotRepo", err)
+	}
+}
+
+func TestHead(t *testing.T) {
+	dir, run := repo(t)
+	r := find(t, dir)
+	first := run("rev-parse", "HEAD")
+	checkHead(t, r, "main", first)
+
+	run("checkout", "-q", "-b", "feature/x")
+	write(t, filepath.Join(dir, "README.md"), "hello again\n")
+	run("commit", "-q", "-am", "second")
+	second := run("rev-parse", "HEAD")
+	checkHead(t, r, "feature/x", second)
+
+	run("pack-refs", "--all")
+	if _, err := os.Stat(filepath.Join(dir, ".git", "refs", "heads", "feature", "x")); !os.IsNotExist(err) {
+		t.Fatal("pack-refs left the loose ref")
+	}
+	checkHead(t, r, "feature/x", second)
+
+	run("checkout", "-q", "--detach", first)
+	checkHead(t, r, "", first)
+
+	empty := t.TempDir()
+	exec.Command("git", "init", "-q", "-b", "trunk", empty).Run()
+	checkHead(t, find(t, empty), "trunk", "")
+}
+
+func TestLinkedWorktree(t *testing.T) {
+	dir, run := repo(t)
+	linked := filepath.Join(t.TempDir(), "linked")
+	run("worktree", "add", "-q", "-b", "hotfix", linked)
+
+	r := find(t, linked)
+	if r.Root != linked || r.CommonDir != filepath.Join(dir, ".git") {
+		t.Errorf("Find(linked) = %+v", r)
+	}
+	checkHead(t, r, "hotfix", run("rev-parse", "HEAD"))
+	checkDirty(t, r, false)
+	write(t, filepath.Join(linked, "README.md"), "changed in the worktree\n")
+	checkDirty(t, r, true)
+	checkDirty(t, find(t, dir), false)
+}
+
+func TestDirty(t *testing.T) {
+	for _, version := range []string{"2", "3", "4"} {
+		t.Run("index v"+version, func(t *testing.T) {
+			dir, run := repo(t)
+			run("update-index", "--index-version", version)
+			r := find(t, dir)
+			checkDirty(t, r, false)
+
+			write(t, filepath.Join(dir, "untracked.txt"), "not seen\n")
+			checkDirty(t, r, false)
+
+			main := filepath.Join(dir, "cmd", "app", "main.go")
+			write(t, main, "package app\n") // same size
+			checkDirty(t, r, true)
+			write(t, main, "package main\n") // changed back, only the mtime differs
+			checkDirty(t, r, false)
+
+			os.Remove(main)
+			checkDirty(t, r, true)
+			run("checkout", "--", ".")
+			checkDirty(t, r, false)
+
+			if err := os.Symlink("README.md", filepath.Join(dir, "link")); err != nil {
+				t.Skip(err)
+			}
+			run("add", "link")
+			run("commit", "-q", "-m", "link")
+			checkDirty(t, r, false)
+			os.Remove(filepath.Join(dir, "link"))
+			os.Symlink("cmd", filepath.Join(dir, "link"))
+			checkDirty(t, r, true)
+		})
+	}
+}
+
+func TestHooksDir(t *testing.T) {
+	dir, run := repo(t)
+	linked := filepath.Join(t.TempDir(), "linked")
+	run("worktree", "add", "-q", linked)
+
+	for _, tt := range []struct {
+		hooksPath string
+		want      string
+	}{
+		{"", filepath.Join(dir, ".git", "hooks")},
+		{".githooks", filepath.Join(dir, ".githooks")},
+		{"/opt/hooks", "/opt/hooks"},
+	} {
+		if tt.hooksPath != "" {
+			run("config", "core.hooksPath", tt.hooksPath)
+		}
+		if got, err := find(t, dir).HooksDir(); err != nil || got != tt.want {
+			t.Errorf("HooksDir() with core.hooksPath %q = %q, %v, want %q", tt.hooksPath, got, err, tt.want)
+		}
+	}
+
+	run("config", "--unset", "core.hooksPath")
+	if got, _ := find(t, linked).HooksDir(); got != filepath.Join(dir, ".git", "hooks") {
+		t.Errorf("linked worktrees share the hooks of the repository, got %q", got)
+	}
+}
@@ -0,0 +1,205 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// indexEntry is a tracked file, https://git-scm.com/docs/index-format
+type indexEntry struct {
+	path         string
+	mtime        time.Time
+	mode         uint32
+	size         uint32 // truncated to 32 bits like git does
+	hash         []byte
+	stage        int  // not 0 while a merge conflict is unresolved
+	skipWorktree bool // sparse checkout, the file isn't expected on disk
+	intentToAdd  bool // `git add -N`, nothing is staged yet
+}
+
+const (
+	modeMask    = 0o170000
+	modeSymlink = 0o120000
+	modeGitlink = 0o160000 // a submodule
+)
+
+// readIndex parses the entries of a version 2, 3 or 4 index.
+// hashSize is 20 for sha1 repositories and 32 for sha256 ones.
+func readIndex(path string, hashSize int) ([]indexEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, fmt.Errorf("%s is not a git index", path)
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported git index version %d", version)
+	}
+	count := binary.BigEndian.Uint32(data[8:12])
+
+	var (
+		entries  []indexEntry
+		previous string // v4 paths are stored as a change of the previous path
+		pos      = 12
+		fixed    = 40 + hashSize + 2
+	)
+	truncated := fmt.Errorf("%s is truncated", path)
+	for range count {
+		if pos+fixed > len(data) {
+			return nil, truncated
+		}
+		start := pos
+		field := func(i int) uint32 { return binary.BigEndian.Uint32(data[start+4*i:]) }
+		entry := indexEntry{
+			mtime: time.Unix(int64(field(2)), int64(field(3))),
+			mode:  field(6),
+			size:  field(9),
+			hash:  data[start+40 : start+40+hashSize],
+		}
+		flags := binary.BigEndian.Uint16(data[start+40+hashSize:])
+		entry.stage = int(flags>>12) & 3
+		pos += fixed
+		if flags&0x4000 != 0 && version >= 3 {
+			if pos+2 > len(data) {
+				return nil, truncated
+			}
+			extended := binary.BigEndian.Uint16(data[pos:])
+			entry.skipWorktree = extended&0x4000 != 0
+			entry.intentToAdd = extended&0x2000 != 0
+			pos += 2
+		}
+
+		var prefix []byte
+		if version == 4 {
+			strip, n := offsetVarint(data[pos:])
+			if n == 0 || strip > len(previous) {
+				return nil, truncated
+			}
+			pos += n
+			prefix = []byte(previous[:len(previous)-strip])
+		}
+		end := bytes.IndexByte(data[pos:], 0)
+		if end < 0 {
+			return nil, truncated
+		}
+		entry.path = string(append(prefix, data[pos:pos+end]...))
+		previous = entry.path
+		pos += end + 1
+		if version < 4 { // padded with 1 to 8 NULs to a multiple of 8
+			pos = start + (pos-1-start+8)&^7
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// offsetVarint decodes the variable length integers of v4 paths, n is 0 when data ends too early
+func offsetVarint(data []byte) (value, n int) {
+	for n < len(data) {
+		b := data[n]
+		n++
+		value |= int(b & 0x7f)
+		if b&0x80 == 0 {
+			return value, n
+		}
+		value = (value + 1) << 7
+	}
+	return 0, 0
+}
+
+// Dirty reports whether a tracked file differs from the index like `git diff --quiet` does,
+// changes that are staged but not committed and untracked files aren't seen.
+// Files whose size and mtime match the index are not read.
+func (r *Repo) Dirty() (bool, error) {
+	_, commit, err := r.Head()
+	if err != nil {
+		return false, err
+	}
+	hashSize := sha1.Size
+	if len(commit) == 2*sha256.Size {
+		hashSize = sha256.Size
+	}
+
+	indexPath := filepath.Join(r.GitDir, "index")
+	index, err := os.Stat(indexPath)
+	if os.IsNotExist(err) { // nothing was ever staged
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	entries, err := readIndex(indexPath, hashSize)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if entry.stage != 0 || entry.intentToAdd {
+			return true, nil
+		}
+		if entry.skipWorktree || entry.mode&modeMask == modeGitlink {
+			continue
+		}
+		changed, err := r.changed(entry, index.ModTime(), hashSize)
+		if err != nil || changed {
+			return changed, err
+		}
+	}
+	return false, nil
+}
+
+// changed compares a file to its index entry, indexed is when the index was written
+func (r *Repo) changed(entry indexEntry, indexed time.Time, hashSize int) (bool, error) {
+	path := filepath.Join(r.Root, filepath.FromSlash(entry.path))
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	symlink := entry.mode&modeMask == modeSymlink
+	if symlink != (info.Mode()&os.ModeSymlink != 0) {
+		return true, nil
+	}
+	if uint32(info.Size()) != entry.size {
+		return true, nil
+	}
+	// a file changed in the same instant the index was written can have a matching mtime, git calls this racy
+	if info.ModTime().Equal(entry.mtime) && info.ModTime().Before(indexed) {
+		return false, nil
+	}
+
+	var content []byte
+	if symlink {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return false, err
+		}
+		content = []byte(target)
+	} else if content, err = os.ReadFile(path); err != nil {
+		return false, err
+	}
+	return !bytes.Equal(blobHash(content, hashSize), entry.hash), nil
+}
+
+// blobHash is the object id git gives content
+func blobHash(content []byte, hashSize int) []byte {
+	var h hash.Hash = sha1.New()
+	if hashSize == sha256.Size {
+		h = sha256.New()
+	}
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return h.Sum(nil)
+}
@@ -203,7 +203,7 @@ func TestRenderWithCustomArgs(t *testing.T) {
 
 	for id := range ids - 1 {
 		behindNow := time.Now().Add(-time.Duration(rand.IntN(7*24)) * time.Hour).Add(-time.Duration(rand.IntN(60)) * time.Minute)
-		store.ChangeTimestamp(db, id, behindNow)
+		db.ChangeTimestamp(id, behindNow)
 	}
 
 	fmt.Println()
@@ -212,9 +212,9 @@ func TestRenderWithCustomArgs(t *testing.T) {
 
 	// daysAgo := rand.IntN(15) + 15
 	// mindtick(fmt.Sprintf("win -%d days ago!", daysAgo))
-	// store.ChangeTimestamp(db, ids, time.Now().Add(-((time.Hour * 24) * time.Duration(daysAgo))))
+	// db.ChangeTimestamp(ids, time.Now().Add(-((time.Hour * 24) * time.Duration(daysAgo))))
 	// mindtick("win -Hello World!") //FIXME: ids not getting right message
-	// store.ChangeTimestamp(db, ids+1, time.Now().Add(-time.Hour*24*time.Duration(30)))
+	// db.ChangeTimestamp(ids+1, time.Now().Add(-time.Hour*24*time.Duration(30)))
 
 	mindtick("view")
 	// mindtick("view task")
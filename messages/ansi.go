@@ -0,0 +1,80 @@
+package messages
+
+import (
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Stdout and Stderr are where mindtick prints, wrap them in a StripWriter to turn colors off
+var (
+	Stdout io.Writer = os.Stdout
+	Stderr io.Writer = os.Stderr
+)
+
+// StripWriter writes to W without ANSI escape sequences,
+// sequences split across writes are still removed
+type StripWriter struct {
+	W     io.Writer
+	state int
+}
+
+const (
+	plainText = iota
+	escape    // after ESC
+	csi       // after ESC [, until a final byte
+)
+
+func (s *StripWriter) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch s.state {
+		case plainText:
+			if b == 0x1b {
+				s.state = escape
+				continue
+			}
+			out = append(out, b)
+		case escape:
+			s.state = plainText
+			if b == '[' {
+				s.state = csi
+			}
+		case csi:
+			if b >= 0x40 && b <= 0x7e {
+				s.state = plainText
+			}
+		}
+	}
+	if _, err := s.W.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// StripANSI removes the escape sequences from s
+func StripANSI(s string) string {
+	if !strings.Contains(s, "\033") {
+		return s
+	}
+	var sb strings.Builder
+	sw := StripWriter{W: &sb}
+	sw.Write([]byte(s))
+	return sb.String()
+}
+
+// VisibleWidth is how many columns s takes up in a terminal, escape sequences take none
+func VisibleWidth(s string) int {
+	return utf8.RuneCountInString(StripANSI(s))
+}
+
+// PadLeft right aligns s to width visible columns
+func PadLeft(s string, width int) string {
+	return strings.Repeat(" ", max(0, width-VisibleWidth(s))) + s
+}
+
+// PadRight left aligns s to width visible columns
+func PadRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-VisibleWidth(s)))
+}
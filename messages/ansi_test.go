@@ -0,0 +1,73 @@
+package messages
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStripWriter(t *testing.T) {
+	colored := ColorizeStr("win", GreenBg, Bold, White) + " " + ColorizeStr("shipped", BrightPurple)
+
+	// split at every byte so sequences straddle writes
+	var sb strings.Builder
+	sw := StripWriter{W: &sb}
+	for i := range len(colored) {
+		sw.Write([]byte{colored[i]})
+	}
+	if got := sb.String(); got != "win shipped" {
+		t.Errorf("StripWriter wrote %q", got)
+	}
+
+	if w := VisibleWidth(colored); w != len("win shipped") {
+		t.Errorf("VisibleWidth = %d", w)
+	}
+	if got := StripANSI(PadLeft(colored, 14)); got != "   win shipped" {
+		t.Errorf("PadLeft = %q", got)
+	}
+}
+
+func TestParseTheme(t *testing.T) {
+	theme, err := ParseTheme(strings.NewReader("# comment\n\ngreen = 71\nred = #ff0000\nwhite=bright-white\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]color{
+		"green": {"\033[38;5;71m", "\033[48;5;71m"},
+		"red":   {"\033[38;2;255;0;0m", "\033[48;2;255;0;0m"},
+		"white": {BrightWhite, BrightWhiteBg},
+	}
+	for name, codes := range want {
+		if theme[name] != codes {
+			t.Errorf("%s = %q, want %q", name, theme[name], codes)
+		}
+	}
+
+	for _, bad := range []string{"green", "pink = red", "green = 256", "green = #ff00"} {
+		if _, err := ParseTheme(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseTheme(%q) expected an error", bad)
+		}
+	}
+	for _, name := range ThemeNames() {
+		if _, ok := BuiltinTheme(name); !ok {
+			t.Errorf("builtin theme %s doesn't load", name)
+		}
+	}
+}
+
+// TestHighContrastTheme checks the promise of its header for every builtin tag
+func TestHighContrastTheme(t *testing.T) {
+	hc, _ := BuiltinTheme("high-contrast")
+	SetTheme(hc)
+	defer SetTheme(nil)
+
+	brightText := []color{BrightWhite, BrightRed, BrightGreen, BrightYellow, BrightBlue, BrightPurple, BrightCyan}
+	darkBg := []color{BlackBg, RedBg, GreenBg, BlueBg, PurpleBg}
+	brightBg := []color{BrightWhiteBg, BrightYellowBg, BrightGreenBg, BrightCyanBg}
+	for _, def := range BuiltinTags {
+		fg, bg := palette(def.Fg)[0], palette(def.Bg)[1]
+		if !(slices.Contains(brightText, fg) && slices.Contains(darkBg, bg)) && !(fg == Black && slices.Contains(brightBg, bg)) {
+			t.Errorf("%s is %q on %q", def.Name, fg, bg)
+		}
+	}
+}
@@ -47,6 +47,7 @@ const (
 	Blink     color = "\033[5m"
 	Reverse   color = "\033[7m"
 	Hidden    color = "\033[8m"
+	Strike    color = "\033[9m"
 )
 
 func ColorizeStr(msg string, c ...color) string {
@@ -56,3 +57,40 @@ func ColorizeStr(msg string, c ...color) string {
 	}
 	return fmt.Sprintf("%s%s%s", colors, msg, reset)
 }
+
+// colorNames are the names accepted by `mindtick tag add --bg --fg`, mapped to their fg and bg codes
+var colorNames = map[string][2]color{
+	"black":         {Black, BlackBg},
+	"red":           {Red, RedBg},
+	"green":         {Green, GreenBg},
+	"yellow":        {Yellow, YellowBg},
+	"blue":          {Blue, BlueBg},
+	"purple":        {Purple, PurpleBg},
+	"cyan":          {Cyan, CyanBg},
+	"white":         {White, WhiteBg},
+	"bright-black":  {BrightBlack, BrightBlackBg},
+	"bright-red":    {BrightRed, BrightRedBg},
+	"bright-green":  {BrightGreen, BrightGreenBg},
+	"bright-yellow": {BrightYellow, BrightYellowBg},
+	"bright-blue":   {BrightBlue, BrightBlueBg},
+	"bright-purple": {BrightPurple, BrightPurpleBg},
+	"bright-cyan":   {BrightCyan, BrightCyanBg},
+	"bright-white":  {BrightWhite, BrightWhiteBg},
+}
+
+var ColorNames = []string{
+	"black", "red", "green", "yellow", "blue", "purple", "cyan", "white",
+	"bright-black", "bright-red", "bright-green", "bright-yellow", "bright-blue", "bright-purple", "bright-cyan", "bright-white",
+}
+
+func IsColorName(name string) bool {
+	_, ok := colorNames[name]
+	return ok
+}
+
+// Prints every color name as a tag for display testing purposes.
+func PrintAllTags() {
+	for _, name := range ColorNames {
+		fmt.Fprintln(Stdout, ColorizeStr(fmt.Sprintf(" %-13s", name), palette(name)[1], Bold, White))
+	}
+}
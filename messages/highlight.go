@@ -0,0 +1,70 @@
+package messages
+
+import (
+	"strings"
+	"unicode"
+)
+
+var highlightColors = []color{Bold, Underline, BrightYellow}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Highlight colors every whole word match of terms in text, case insensitive.
+// A term ending in `*` matches any word starting with it, terms with spaces match phrases.
+func Highlight(text string, terms []string) string {
+	if len(terms) == 0 {
+		return text
+	}
+
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(runes))
+	for _, term := range terms {
+		prefix := strings.HasSuffix(term, "*")
+		needle := []rune(strings.ToLower(strings.TrimSuffix(term, "*")))
+		if len(needle) == 0 {
+			continue
+		}
+
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if i > 0 && isWordRune(lower[i-1]) {
+				continue
+			}
+			if string(lower[i:i+len(needle)]) != string(needle) {
+				continue
+			}
+			end := i + len(needle)
+			if prefix {
+				for end < len(lower) && isWordRune(lower[end]) {
+					end++
+				}
+			} else if end < len(lower) && isWordRune(lower[end]) {
+				continue
+			}
+			for j := i; j < end; j++ {
+				marked[j] = true
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			sb.WriteString(ColorizeStr(string(runes[i:j]), highlightColors...))
+		} else {
+			sb.WriteString(string(runes[i:j]))
+		}
+		i = j
+	}
+	return sb.String()
+}
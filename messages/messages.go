@@ -2,117 +2,57 @@ package messages
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// bright purple, cyan, green, yellow
-var (
-	// winTag  = ColorizeStr("  win", Bold, BrightWhite, GreenBg)
-	// winBg   = ColorizeStr("     ", Bold, BrightWhite, GreenBg)
-	// noteTag = ColorizeStr(" note", Bold, BrightWhite, CyanBg)
-	// noteBg  = ColorizeStr("     ", Bold, BrightWhite, CyanBg)
-	// fixTag  = ColorizeStr("  fix", Bold, BrightWhite, YellowBg)
-	// fixBg   = ColorizeStr("     ", Bold, BrightWhite, YellowBg)
-	// taskTag = ColorizeStr(" task", Bold, BrightWhite, PurpleBg)
-	// taskBg  = ColorizeStr("     ", Bold, BrightWhite, PurpleBg)
-
-	redTag          = ColorizeStr("red", Bold, Red, RedBg)
-	blackTag        = ColorizeStr("black", Bold, BlackBg)
-	whiteTag        = ColorizeStr("white", Bold, WhiteBg)
-	greenTag        = ColorizeStr("green", Bold, Green, GreenBg)
-	yellowTag       = ColorizeStr("yellow", Bold, Yellow, YellowBg)
-	blueTag         = ColorizeStr("blue", Bold, Blue, BlueBg)
-	purpleTag       = ColorizeStr("purple", Bold, Purple, PurpleBg)
-	cyanTag         = ColorizeStr("cyan", Bold, Cyan, CyanBg)
-	brightBlackTag  = ColorizeStr("brightBlack", Bold, BrightBlack, BrightBlackBg)
-	brightRedTag    = ColorizeStr("brightRed", Bold, BrightRed, BrightRedBg)
-	brightGreenTag  = ColorizeStr("brightGreen", Bold, BrightGreen, BrightGreenBg)
-	brightYellowTag = ColorizeStr("brightYellow", Bold, BrightYellow, BrightYellowBg)
-	brightBlueTag   = ColorizeStr("brightBlue", Bold, BrightBlue, BrightBlueBg)
-	brightPurpleTag = ColorizeStr("brightPurple", Bold, BrightPurple, BrightPurpleBg)
-	brightCyanTag   = ColorizeStr("brightCyan", Bold, BrightCyan, BrightCyanBg)
-	brightWhiteTag  = ColorizeStr("brightWhite", Bold, BrightWhite, BrightWhiteBg)
-)
-
 const (
 	BGTITLE = false
 	ONLYBG  = true
 )
 
-// Prints all tags for display testing purposes.
-func PrintAllTags() {
-	tags := []string{
-		winTag, noteTag, fixTag, redTag, blackTag, whiteTag, greenTag, yellowTag,
-		blueTag, purpleTag, cyanTag, brightBlackTag, brightRedTag, brightGreenTag,
-		brightYellowTag, brightBlueTag, brightPurpleTag, brightCyanTag, brightWhiteTag,
-	}
+// Status is the lifecycle of a task, other messages are always OPEN
+type Status int
+
+const (
+	OPEN Status = iota
+	DONE
+	CANCELLED
+)
 
-	for _, tag := range tags {
-		fmt.Println(tag)
+var (
+	StatusToStr = map[Status]string{
+		OPEN:      "open",
+		DONE:      "done",
+		CANCELLED: "cancelled",
 	}
-}
+	StrToStatus = map[string]Status{
+		"open":      OPEN,
+		"done":      DONE,
+		"cancelled": CANCELLED,
+	}
+)
 
-type Tag uint8
+// Priority orders open tasks in `mindtick todo`, every message starts out NORMAL
+type Priority int
 
 const (
-	ANYTAG Tag = iota
-	WIN
-	NOTE
-	FIX
-	TASK
-	URL
-	WORK
-	ALERT
+	LOW    Priority = -1
+	NORMAL Priority = 0
+	HIGH   Priority = 1
 )
 
-// TODO: FIXME: refactor this mess when impl custom tags
-// To add a hardcoded tag, follow these steps:
-// 1. Add a new constant to the Tag type
-// 2. Add a new constant to the Tags map
-// 3. Add a new constant to the bgs map
-// 4. Add a new constant to the StrToTag map
 var (
-	winTag   = ColorizeStr("  win", GreenBg, Bold, White)
-	winBg    = ColorizeStr("     ", GreenBg, Bold, White)
-	noteTag  = ColorizeStr(" note", CyanBg, Bold, White)
-	noteBg   = ColorizeStr("     ", CyanBg, Bold, White)
-	fixTag   = ColorizeStr("  fix", BrightYellowBg, Bold, Black)
-	fixBg    = ColorizeStr("     ", BrightYellowBg, Bold, Black)
-	taskTag  = ColorizeStr(" task", BrightPurpleBg, Bold, White)
-	taskBg   = ColorizeStr("     ", BrightPurpleBg, Bold, White)
-	urlTag   = ColorizeStr("  url", BlackBg, Bold, Blue)
-	urlBg    = ColorizeStr("     ", BlackBg, Bold, Blue)
-	workTag  = ColorizeStr(" work", BrightWhiteBg, Bold, Black)
-	workBg   = ColorizeStr("     ", BrightWhiteBg, Bold, Black)
-	alertTag = ColorizeStr("ALERT", RedBg, Bold, White)
-	alertBg  = ColorizeStr("     ", RedBg, Bold, White)
-	TagOrder = []Tag{WIN, NOTE, FIX, TASK, URL, WORK, ALERT}
-	StrToTag = map[string]Tag{
-		"win":   WIN,
-		"note":  NOTE,
-		"fix":   FIX,
-		"task":  TASK,
-		"url":   URL,
-		"work":  WORK,
-		"alert": ALERT,
-	}
-	Tags = map[Tag]string{
-		WIN:   winTag,
-		NOTE:  noteTag,
-		FIX:   fixTag,
-		TASK:  taskTag,
-		URL:   urlTag,
-		WORK:  workTag,
-		ALERT: alertTag,
-	}
-	bgs = map[Tag]string{
-		WIN:   winBg,
-		NOTE:  noteBg,
-		FIX:   fixBg,
-		TASK:  taskBg,
-		URL:   urlBg,
-		WORK:  workBg,
-		ALERT: alertBg,
+	PriorityToStr = map[Priority]string{
+		LOW:    "low",
+		NORMAL: "normal",
+		HIGH:   "high",
+	}
+	StrToPriority = map[string]Priority{
+		"low": LOW, "l": LOW,
+		"normal": NORMAL, "n": NORMAL,
+		"high": HIGH, "h": HIGH,
 	}
 )
 
@@ -120,11 +60,50 @@ var (
 // timestamp DATETIME,
 // msg TEXT,
 // msgtype INT
+// status INT
+// completed_at DATETIME, NULL while open
+// due DATETIME, NULL without a due date
+// priority INT
+// git_repo, git_branch, git_commit TEXT, git_dirty INT
+// private INT
 type Message struct {
-	Timestamp time.Time `db:"timestamp"`
-	Msg       string    `db:"msg"`
-	ID        int       `db:"id"`
-	Tag       Tag       `db:"msgtype"`
+	Timestamp   time.Time `db:"timestamp"`
+	Msg         string    `db:"msg"`
+	ID          int       `db:"id"`
+	Tag         Tag       `db:"msgtype"`
+	Status      Status    `db:"status"`
+	CompletedAt time.Time `db:"completed_at"`
+	Due         time.Time `db:"due"` // the task is overdue from this instant on
+	Priority    Priority  `db:"priority"`
+	Git         Git       // where the message was added
+	Private     bool      `db:"private"` // left out of client reports
+}
+
+// Git is the git work tree a message was added in, empty outside of one
+type Git struct {
+	Repo   string `json:"repo"`             // the name of the work tree directory
+	Branch string `json:"branch,omitempty"` // empty on a detached HEAD
+	Commit string `json:"commit,omitempty"` // HEAD, empty before the first commit
+	Dirty  bool   `json:"dirty,omitempty"`  // tracked files differed from the index
+}
+
+// RenderGit renders g as repo branch 1a2b3c4, dirty work trees are marked with a *
+func RenderGit(g Git) string {
+	if g.Repo == "" {
+		return ""
+	}
+	parts := []string{g.Repo, g.Branch}
+	if g.Branch == "" {
+		parts[1] = "detached"
+	}
+	if g.Commit != "" {
+		parts = append(parts, g.Commit[:min(7, len(g.Commit))])
+	}
+	str := strings.Join(parts, " ")
+	if g.Dirty {
+		str += "*"
+	}
+	return ColorizeStr(str, BrightBlack)
 }
 
 func renderTime(t time.Time) string {
@@ -135,27 +114,49 @@ func renderTime(t time.Time) string {
 	return tStr
 }
 
-func RenderTag(msgType Tag, bgOnly bool) string {
-	var tag string
+func RenderMsg(msg Message, bgOnly bool) string {
+	return RenderMsgWith(RenderOptions{}, msg, bgOnly)
+}
 
-	if bgOnly {
-		tag = bgs[msgType]
-	} else {
-		tag = Tags[msgType]
+// RenderMsgWith renders msg with the text options of opts, the columns are added by RenderMessagesWith
+func RenderMsgWith(opts RenderOptions, msg Message, bgOnly bool) string {
+	text := msg.Msg
+	if len(opts.Highlight) > 0 {
+		text = Highlight(text, opts.Highlight)
+	}
+	if opts.Status && msg.Status != OPEN {
+		text = renderClosed(msg.Status, text)
+	}
+	if opts.Git && msg.Git.Repo != "" {
+		text += "  " + RenderGit(msg.Git)
+	}
+	if msg.Private {
+		text += "  " + ColorizeStr("private", Dim)
 	}
-	tag = fmt.Sprintf("%*s", 23, tag)
-	// 23 is the length of the longest tag bc of ANSI color
+	return renderMsg(msg, bgOnly, text)
+}
 
-	return tag
+// renderClosed strikes through text, keeping it struck after any colors inside it reset
+func renderClosed(status Status, text string) string {
+	colors := []color{Strike}
+	if status == CANCELLED {
+		colors = append(colors, Dim)
+	}
+	var codes string
+	for _, c := range colors {
+		codes += string(c)
+	}
+	return ColorizeStr(strings.ReplaceAll(text, string(reset), string(reset)+codes), colors...)
 }
 
-func RenderMsg(msg Message, bgOnly bool) string {
+// renderMsg renders msg with text in place of msg.Msg
+func renderMsg(msg Message, bgOnly bool, text string) string {
 	var (
 		tag  = RenderTag(msg.Tag, bgOnly)
 		time = renderTime(msg.Timestamp)
 	)
 
-	return fmt.Sprintf("%s %s     %s", tag, time, msg.Msg)
+	return fmt.Sprintf("%s %s     %s", tag, time, text)
 }
 
 func RenderDate(d time.Time) string {
@@ -164,39 +165,94 @@ func RenderDate(d time.Time) string {
 	return date
 }
 
+// RenderOptions toggles the optional columns of RenderMessagesWith
+type RenderOptions struct {
+	IDs       bool     // dim id column, right aligned to the widest id
+	Highlight []string // search terms to highlight, see Highlight
+	Status    bool     // strike through done tasks, cancelled ones are also dimmed
+	Git       bool     // the repo, branch and commit a message was added on, see RenderGit
+}
+
+func renderID(id int, width int) string {
+	return ColorizeStr(fmt.Sprintf("%*d", width, id), Dim)
+}
+
 // will always be sorted by timestamp
 func RenderMessages(msgs ...Message) {
+	RenderMessagesWith(RenderOptions{}, msgs...)
+}
+
+// RenderMessagesWith renders msgs under a date header for each day they were added on
+func RenderMessagesWith(opts RenderOptions, msgs ...Message) {
+	if len(msgs) == 0 {
+		return
+	}
+	idWidth := 0
+	if opts.IDs {
+		for i := range msgs {
+			idWidth = max(idWidth, len(strconv.Itoa(msgs[i].ID)))
+		}
+	}
+
 	curDate := msgs[0].Timestamp
 	curType := ANYTAG
-	fmt.Println(RenderDate(curDate))
+	fmt.Fprintln(Stdout, RenderDate(curDate))
 
 	for i := range msgs {
-		if curDate.Day() != msgs[i].Timestamp.Day() {
+		if curDate.Format(time.DateOnly) != msgs[i].Timestamp.Format(time.DateOnly) {
 			curDate = msgs[i].Timestamp
 			curType = ANYTAG
-			fmt.Println("\n" + RenderDate(curDate))
+			fmt.Fprintln(Stdout, "\n"+RenderDate(curDate))
 		}
 
+		var line string
 		if curType != msgs[i].Tag {
 			curType = msgs[i].Tag
-			fmt.Println(RenderMsg(msgs[i], BGTITLE))
+			line = RenderMsgWith(opts, msgs[i], BGTITLE)
 		} else {
-			fmt.Println(RenderMsg(msgs[i], ONLYBG))
+			line = RenderMsgWith(opts, msgs[i], ONLYBG)
+		}
+
+		if opts.IDs {
+			line = renderID(msgs[i].ID, idWidth) + " " + line
 		}
+		fmt.Fprintln(Stdout, line)
 	}
 }
 
-func NewMessage(tagStr string, msg string) (Message, error) {
-	tag := StrToTag[tagStr]
+// RenderAge renders how long ago t was as 5m, 3h or 12d
+func RenderAge(t time.Time, now time.Time) string {
+	age := now.Sub(t)
+	switch {
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
 
-	// if tag == ANYTAG { // should never get here
-	// 	panic("unknown view tag " + tagStr)
-	// 	// return Message{}, fmt.Errorf("unknown view tag %s", tagStr)
-	// }
+// RenderDue renders a due date as Fri Oct 23, with the time when it isn't the end of a day
+func RenderDue(due time.Time) string {
+	if due.Equal(time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, due.Location())) {
+		// due at midnight is due by the end of the day before
+		return due.AddDate(0, 0, -1).Format("Mon Jan 02")
+	}
+	return due.Format("Mon Jan 02 03:04 PM")
+}
+
+// Now is the clock of NewMessage and the commands, tests replace it
+var Now = time.Now
+
+func NewMessage(tagStr string, msg string) (Message, error) {
+	def, ok := LookupTag(tagStr)
+	if !ok {
+		return Message{}, fmt.Errorf("unknown tag %s", tagStr)
+	}
 
 	return Message{
-		Timestamp: time.Now(),
+		Timestamp: Now(),
 		Msg:       msg,
-		Tag:       tag,
+		Tag:       def.ID,
 	}, nil
 }
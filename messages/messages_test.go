@@ -0,0 +1,35 @@
+package messages
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderMessagesDates(t *testing.T) {
+	var out bytes.Buffer
+	stdout := Stdout
+	Stdout = &StripWriter{W: &out}
+	defer func() { Stdout = stdout }()
+
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, time.Local) }
+	RenderMessages()
+	// the same day of another month or year still gets its own header
+	RenderMessages(
+		Message{Timestamp: day(2026, 3, 12), Tag: WIN, Msg: "march"},
+		Message{Timestamp: day(2026, 3, 12).Add(time.Hour), Tag: WIN, Msg: "march again"},
+		Message{Timestamp: day(2026, 4, 12), Tag: WIN, Msg: "april"},
+		Message{Timestamp: day(2027, 4, 12), Tag: WIN, Msg: "next april"},
+	)
+	var headers []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "[ ") {
+			headers = append(headers, line)
+		}
+	}
+	want := []string{"[ Mar 12, 2026 ]", "[ Apr 12, 2026 ]", "[ Apr 12, 2027 ]"}
+	if strings.Join(headers, "\n") != strings.Join(want, "\n") {
+		t.Errorf("headers = %q, want %q", headers, want)
+	}
+}
@@ -0,0 +1,118 @@
+package messages
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Tag int
+
+// ids of the tags every store starts with, see BuiltinTags
+const (
+	ANYTAG Tag = iota
+	WIN
+	NOTE
+	FIX
+	TASK
+	URL
+	WORK
+	ALERT
+)
+
+// TagDef is a row of the tags table in a store.mindtick
+type TagDef struct {
+	ID   Tag
+	Name string // matched case insensitively, rendered as is
+	Bg   string // one of ColorNames
+	Fg   string // one of ColorNames
+}
+
+// BuiltinTags are the tags a new store is seeded with
+var BuiltinTags = []TagDef{
+	{ID: WIN, Name: "win", Bg: "green", Fg: "white"},
+	{ID: NOTE, Name: "note", Bg: "cyan", Fg: "white"},
+	{ID: FIX, Name: "fix", Bg: "bright-yellow", Fg: "black"},
+	{ID: TASK, Name: "task", Bg: "bright-purple", Fg: "white"},
+	{ID: URL, Name: "url", Bg: "black", Fg: "blue"},
+	{ID: WORK, Name: "work", Bg: "bright-white", Fg: "black"},
+	{ID: ALERT, Name: "ALERT", Bg: "red", Fg: "white"},
+}
+
+// IsBuiltin reports whether tag is one of BuiltinTags, which tasks, reports and themes rely on
+func IsBuiltin(tag Tag) bool {
+	return tag >= WIN && tag <= ALERT
+}
+
+// the tags of the loaded store, replaced with SetTags
+var (
+	tagDefs  []TagDef
+	tagWidth int
+)
+
+func init() {
+	SetTags(BuiltinTags)
+}
+
+// SetTags replaces the known tags, store.Open calls this with the tags of the store
+func SetTags(defs []TagDef) {
+	tagDefs = defs
+	tagWidth = 5
+	for _, def := range defs {
+		tagWidth = max(tagWidth, len(def.Name))
+	}
+}
+
+// TagDefs returns the known tags in the order they were created
+func TagDefs() []TagDef {
+	return tagDefs
+}
+
+func TagNames() []string {
+	var names []string
+	for _, def := range tagDefs {
+		names = append(names, strings.ToLower(def.Name))
+	}
+	return names
+}
+
+func LookupTag(name string) (TagDef, bool) {
+	for _, def := range tagDefs {
+		if strings.EqualFold(def.Name, name) {
+			return def, true
+		}
+	}
+	return TagDef{}, false
+}
+
+func TagByID(tag Tag) (TagDef, bool) {
+	for _, def := range tagDefs {
+		if def.ID == tag {
+			return def, true
+		}
+	}
+	return TagDef{}, false
+}
+
+// Render colors label with the tag's colors
+func (def TagDef) Render(label string) string {
+	return ColorizeStr(label, palette(def.Bg)[1], Bold, palette(def.Fg)[0])
+}
+
+// RenderBar renders a bar of n #s in the background color of the tag, for charts
+func (def TagDef) RenderBar(n int) string {
+	return ColorizeStr(strings.Repeat("#", n), palette(def.Bg)[0])
+}
+
+// RenderTag renders the tag name right aligned to the longest tag name,
+// bgOnly renders just the background for messages under the same tag
+func RenderTag(msgType Tag, bgOnly bool) string {
+	def, ok := TagByID(msgType)
+	if !ok {
+		def = TagDef{ID: msgType, Name: "?", Bg: "bright-black", Fg: "white"}
+	}
+
+	if bgOnly {
+		return def.Render(strings.Repeat(" ", tagWidth))
+	}
+	return def.Render(fmt.Sprintf("%*s", tagWidth, def.Name))
+}
@@ -0,0 +1,107 @@
+package messages
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Theme remaps the color names of tags to other colors, names it leaves out keep theirs.
+// Theme files have a `name = color` line per color name, where color is another
+// color name, a 256 color palette index like 141 or a truecolor #rrggbb.
+// Blank lines and lines starting with # are ignored.
+type Theme map[string][2]color
+
+//go:embed themes/*.theme
+var builtinThemes embed.FS
+
+// the theme tags are rendered with, nil is the default palette
+var theme Theme
+
+// SetTheme makes tags render with t, nil restores the default palette
+func SetTheme(t Theme) {
+	theme = t
+}
+
+// ThemeNames are the themes that ship with mindtick, "default" included
+func ThemeNames() []string {
+	names := []string{"default"}
+	entries, _ := builtinThemes.ReadDir("themes")
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".theme"))
+	}
+	slices.Sort(names[1:])
+	return names
+}
+
+// BuiltinTheme returns the theme shipped as name
+func BuiltinTheme(name string) (Theme, bool) {
+	if name == "default" {
+		return nil, true
+	}
+	f, err := builtinThemes.Open("themes/" + name + ".theme")
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	t, err := ParseTheme(f)
+	if err != nil {
+		panic(fmt.Sprintf("builtin theme %s: %v", name, err))
+	}
+	return t, true
+}
+
+// ParseTheme reads a theme file
+func ParseTheme(r io.Reader) (Theme, error) {
+	t := Theme{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(text, "=")
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.ToLower(strings.TrimSpace(value))
+		if !ok || !IsColorName(name) {
+			return nil, fmt.Errorf("line %d: expected %s", line, ColorizeStr("color-name = color", BrightPurple))
+		}
+		codes, err := parseThemeColor(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		t[name] = codes
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read theme: %v", err)
+	}
+	return t, nil
+}
+
+// parseThemeColor returns the fg and bg codes of a color name, a 256 color index or #rrggbb
+func parseThemeColor(value string) ([2]color, error) {
+	if codes, ok := colorNames[value]; ok {
+		return codes, nil
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 255 {
+		return [2]color{color(fmt.Sprintf("\033[38;5;%dm", n)), color(fmt.Sprintf("\033[48;5;%dm", n))}, nil
+	}
+	if hex, ok := strings.CutPrefix(value, "#"); ok && len(hex) == 6 {
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			r, g, b := v>>16&0xff, v>>8&0xff, v&0xff
+			return [2]color{color(fmt.Sprintf("\033[38;2;%d;%d;%dm", r, g, b)), color(fmt.Sprintf("\033[48;2;%d;%d;%dm", r, g, b))}, nil
+		}
+	}
+	return [2]color{}, fmt.Errorf("unknown color %s, use a color name, 0-255 or #rrggbb", ColorizeStr(value, BrightPurple))
+}
+
+// palette returns the fg and bg codes of a color name under the current theme
+func palette(name string) [2]color {
+	if codes, ok := theme[name]; ok {
+		return codes
+	}
+	return colorNames[name]
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Global makes LoadMindtick, New and Delete use the per user store at GlobalPath
+// instead of the nearest store.mindtick, set by `mindtick -g`
+var Global bool
+
+// Dir is where the nearest store.mindtick is looked up from and where New and Delete
+// work, the working directory when empty
+var Dir string
+
+// workDir is Dir or the working directory
+func workDir() (string, error) {
+	if Dir != "" {
+		return filepath.Abs(Dir)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("unable to get access to directory: %v", err)
+	}
+	return dir, nil
+}
+
+// ShortPath is how messages name the store at path, relative to the working
+// directory unless it is the global store
+func ShortPath(path string) string {
+	dir, err := workDir()
+	if err != nil {
+		return path
+	}
+	if global, err := GlobalPath(); err == nil && filepath.Dir(global) == filepath.Dir(path) {
+		return path
+	}
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// FallbackEnv set to "global" makes LoadMindtick use the global store
+// when no store.mindtick is found walking up from the current directory
+const FallbackEnv = "MINDTICK_FALLBACK"
+
+// GlobalPath is $XDG_DATA_HOME/mindtick/store.mindtick, defaulting to ~/.local/share
+func GlobalPath() (string, error) {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("unable to find the global %s: %v", COLORDBFILENAME, err)
+		}
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataDir, "mindtick", DBFileName), nil
+}
+
+// globalDir is the directory of GlobalPath, created so Open can create the store on first use
+func globalDir() (string, error) {
+	path, err := GlobalPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("unable to create the global %s directory: %v", COLORDBFILENAME, err)
+	}
+	return filepath.Dir(path), nil
+}
+
+// globalStore returns the global store.mindtick or mindtick.jsonl. Without one it is
+// an error, unless create is set and GlobalPath is returned for Open to create it.
+func globalStore(create bool) (string, error) {
+	path, err := GlobalPath()
+	if err != nil {
+		return "", err
+	}
+	if existing, ok := storeIn(filepath.Dir(path)); ok {
+		return existing, nil
+	}
+	if !create {
+		return "", fmt.Errorf("global %s not found\n%s to create it, adding a message creates it too", COLORDBFILENAME,
+			messages.ColorizeStr("mindtick -g new", messages.BrightGreen))
+	}
+	if _, err := globalDir(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
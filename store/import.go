@@ -0,0 +1,164 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Imported is a message to import, its tag is a name so missing tags can be created.
+// Timestamps are stored in time.Local like the messages mindtick adds, whatever zone they were written in.
+// Exports don't keep when a task was completed, done and cancelled tasks are completed at their timestamp.
+type Imported struct {
+	Timestamp time.Time
+	Tag       string
+	Text      string
+	Status    messages.Status
+	Due       time.Time
+	Priority  messages.Priority
+	Private   bool
+}
+
+// message is entry as a message with tag
+func (entry Imported) message(tag messages.Tag) messages.Message {
+	msg := messages.Message{Timestamp: entry.Timestamp.In(time.Local), Tag: tag, Msg: entry.Text,
+		Status: entry.Status, Priority: entry.Priority, Private: entry.Private}
+	if entry.Status != messages.OPEN {
+		msg.CompletedAt = msg.Timestamp
+	}
+	if !entry.Due.IsZero() {
+		msg.Due = entry.Due.In(time.Local)
+	}
+	return msg
+}
+
+// ImportResult is what Store.Import did, or would have done on a dry run.
+// Unknown tags are created when createTags is set, otherwise nothing is imported.
+// Messages already in the store, or earlier in the entries, are skipped as duplicates.
+// The tags messages renders include any created tags, even on a dry run.
+type ImportResult struct {
+	Added      []messages.Message
+	Duplicates []messages.Message
+	NewTags    []string
+}
+
+// duplicateKey identifies a message by timestamp, tag and text.
+// Timestamps compare to the second since not every format keeps more.
+type duplicateKey struct {
+	unix int64
+	tag  messages.Tag
+	text string
+}
+
+func keyOf(msg messages.Message) duplicateKey {
+	return duplicateKey{unix: msg.Timestamp.Unix(), tag: msg.Tag, text: msg.Msg}
+}
+
+// the colors of tags created by an import
+const (
+	importedTagBg = "bright-black"
+	importedTagFg = "white"
+)
+
+// missingTags returns the lowercased tag names of entries that aren't in tagIDs,
+// an error unless createTags is set. Missing names are added to tagIDs as messages.ANYTAG.
+func missingTags(entries []Imported, tagIDs map[string]messages.Tag, createTags bool) ([]string, error) {
+	var missing []string
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Tag)
+		if _, ok := tagIDs[name]; !ok {
+			missing = append(missing, name)
+			tagIDs[name] = messages.ANYTAG
+		}
+	}
+	if len(missing) > 0 && !createTags {
+		return nil, fmt.Errorf("unknown tags %s\ncreate them with %s or add them first with %s",
+			messages.ColorizeStr(strings.Join(missing, ", "), messages.BrightPurple),
+			messages.ColorizeStr("--create-tags", messages.BrightGreen), messages.ColorizeStr("mindtick tag add", messages.BrightGreen))
+	}
+	return missing, nil
+}
+
+// `mindtick import` command, everything happens in one transaction that is rolled back on dryRun
+func (s *SQLite) Import(entries []Imported, createTags, dryRun bool) (ImportResult, error) {
+	var result ImportResult
+	tx, err := begin(s.db)
+	if err != nil {
+		return result, fmt.Errorf("unable to start import: %v", err)
+	}
+	defer tx.Rollback()
+
+	defs, err := tags(tx)
+	if err != nil {
+		return result, err
+	}
+	tagIDs := map[string]messages.Tag{}
+	for _, def := range defs {
+		tagIDs[strings.ToLower(def.Name)] = def.ID
+	}
+
+	missing, err := missingTags(entries, tagIDs, createTags)
+	if err != nil {
+		return result, err
+	}
+	for _, name := range missing {
+		res, err := tx.Exec("INSERT INTO tags (name, bg, fg) VALUES (?, ?, ?)", name, importedTagBg, importedTagFg)
+		if err != nil {
+			return result, fmt.Errorf("unable to create tag %s: %v", name, err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return result, fmt.Errorf("unable to create tag %s: %v", name, err)
+		}
+		tagIDs[name] = messages.Tag(id)
+		result.NewTags = append(result.NewTags, name)
+	}
+
+	rows, err := tx.Query("SELECT " + messageColumns + " FROM messages")
+	if err != nil {
+		return result, fmt.Errorf("unable to query messages: %v", err)
+	}
+	existing, err := processRows(rows)
+	rows.Close()
+	if err != nil {
+		return result, err
+	}
+	seen := map[duplicateKey]bool{}
+	for _, msg := range existing {
+		seen[keyOf(msg)] = true
+	}
+
+	for _, entry := range entries {
+		msg := entry.message(tagIDs[strings.ToLower(entry.Tag)])
+		if seen[keyOf(msg)] {
+			result.Duplicates = append(result.Duplicates, msg)
+			continue
+		}
+		seen[keyOf(msg)] = true
+
+		res, err := tx.Exec("INSERT INTO messages (timestamp, msg, msgtype, status, completed_at, due, priority, private) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			msg.Timestamp, msg.Msg, msg.Tag, msg.Status, nullTime(msg.CompletedAt), nullTime(msg.Due), msg.Priority, msg.Private)
+		if err != nil {
+			return result, fmt.Errorf("unable to import message: %v", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return result, fmt.Errorf("unable to import message: %v", err)
+		}
+		msg.ID = int(id)
+		result.Added = append(result.Added, msg)
+	}
+
+	if err := s.loadTags(tx); err != nil {
+		return result, err
+	}
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("unable to commit import: %v", err)
+	}
+	return result, nil
+}
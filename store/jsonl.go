@@ -0,0 +1,454 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// JSONL is a Store kept in an append only log with a JSON object per change,
+// so the history of a mindtick can be diffed, grepped and committed.
+// The log is replayed into a Memory when it is opened. Every change locks
+// the log and replays what other mindticks appended before it is written.
+type JSONL struct {
+	*Memory
+	file   *os.File
+	offset int64 // bytes of the log replayed into Memory
+	lines  int   // lines replayed, for errors
+
+	// events name messages by the uid of their add, ids differ when logs are merged
+	uids map[int]string
+	ids  map[string]int
+}
+
+// jsonlVersion is the version of the event format, recorded in the first line of a log
+const jsonlVersion = 1
+
+// event is a line of a JSONL log, tags are referenced by name
+type event struct {
+	Op        string        `json:"op"`
+	Version   int           `json:"version,omitempty"`
+	ID        int           `json:"id,omitempty"`
+	UID       string        `json:"uid,omitempty"` // the message, logs written before uids only have the id
+	Timestamp *time.Time    `json:"timestamp,omitempty"`
+	Tag       string        `json:"tag,omitempty"`
+	Text      string        `json:"text,omitempty"`
+	Due       *time.Time    `json:"due,omitempty"`
+	Priority  string        `json:"priority,omitempty"`
+	Status    string        `json:"status,omitempty"`
+	Git       *messages.Git `json:"git,omitempty"`
+	Private   bool          `json:"private,omitempty"`
+	At        *time.Time    `json:"at,omitempty"`
+	Name      string        `json:"name,omitempty"`
+	Bg        string        `json:"bg,omitempty"`
+	Fg        string        `json:"fg,omitempty"`
+	Reassign  string        `json:"reassign,omitempty"`
+	Key       string        `json:"key,omitempty"`
+	Value     string        `json:"value,omitempty"`
+}
+
+// the ops of events
+const (
+	opHeader    = "mindtick"
+	opAdd       = "add"
+	opEdit      = "edit"
+	opRetag     = "retag"
+	opRetime    = "retime"
+	opStatus    = "status"
+	opPrivate   = "private"
+	opPublic    = "public"
+	opDelete    = "delete"
+	opAddTag    = "tag"
+	opRenameTag = "rename-tag"
+	opRemoveTag = "remove-tag"
+	opSet       = "set"
+)
+
+// OpenJSONL opens or creates the log at path and replays it
+func OpenJSONL(path string) (*JSONL, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %v", path, err)
+	}
+	j := &JSONL{Memory: NewMemory(), file: file, uids: map[int]string{}, ids: map[string]int{}}
+
+	// the header is only written by the mindtick that finds the log empty
+	err = j.locked(func() error {
+		if j.lines > 0 {
+			return nil
+		}
+		return j.write(event{Op: opHeader, Version: jsonlVersion})
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// replay applies the lines appended since the last replay
+func (j *JSONL) replay() error {
+	if _, err := j.file.Seek(j.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to read %s: %v", j.file.Name(), err)
+	}
+	r := bufio.NewReaderSize(j.file, 64*1024)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			j.lines++
+			if err := j.replayLine(bytes.TrimSuffix(line, []byte("\n"))); err != nil {
+				return fmt.Errorf("unable to read %s line %d: %v", j.file.Name(), j.lines, err)
+			}
+			j.offset += int64(len(line))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %v", j.file.Name(), err)
+		}
+	}
+}
+
+func (j *JSONL) replayLine(line []byte) error {
+	var e event
+	if err := json.Unmarshal(line, &e); err != nil {
+		return err
+	}
+	if j.lines == 1 {
+		return checkHeader(e)
+	}
+	return j.apply(e)
+}
+
+// locked runs change with the log locked, after replaying what other mindticks appended
+func (j *JSONL) locked(change func() error) error {
+	if err := lockFile(j.file); err != nil {
+		return fmt.Errorf("unable to lock %s: %v", j.file.Name(), err)
+	}
+	defer unlockFile(j.file)
+	if err := j.replay(); err != nil {
+		return err
+	}
+	return change()
+}
+
+func checkHeader(e event) error {
+	if e.Op != opHeader {
+		return fmt.Errorf("not a mindtick log, the first line must be %s", messages.ColorizeStr(`{"op":"mindtick","version":1}`, messages.BrightPurple))
+	}
+	if e.Version > jsonlVersion {
+		return fmt.Errorf("the log uses version %d but this mindtick only supports up to %d\nupgrade with %s",
+			e.Version, jsonlVersion, messages.ColorizeStr("go install github.com/ninesl/mindtick@latest", messages.BrightGreen))
+	}
+	return nil
+}
+
+func (j *JSONL) Path() string {
+	return j.file.Name()
+}
+
+func (j *JSONL) Close() error {
+	return j.file.Close()
+}
+
+// write appends e to the log, a line is written with a single write.
+// Only call it while locked, the log must not have grown since the replay.
+func (j *JSONL) write(e event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("unable to encode %s: %v", e.Op, err)
+	}
+	n, err := j.file.Write(append(line, '\n'))
+	j.offset += int64(n)
+	j.lines++
+	if err != nil {
+		return fmt.Errorf("unable to write to %s: %v", j.file.Name(), err)
+	}
+	return nil
+}
+
+// tagName is the name events use for tag
+func (j *JSONL) tagName(tag messages.Tag) string {
+	if i, ok := j.tagIndex(tag); ok {
+		return j.tags[i].Name
+	}
+	return ""
+}
+
+// tagID resolves the tag name of an event
+func (j *JSONL) tagID(name string) (messages.Tag, error) {
+	def, ok := j.tagNamed(name)
+	if !ok {
+		return messages.ANYTAG, fmt.Errorf("unknown tag %q", name)
+	}
+	return def.ID, nil
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// logAdd appends the add of msg with a new uid
+func (j *JSONL) logAdd(msg messages.Message) error {
+	uid := make([]byte, 8)
+	if _, err := rand.Read(uid); err != nil {
+		return fmt.Errorf("unable to add message: %v", err)
+	}
+	e := event{Op: opAdd, ID: msg.ID, UID: hex.EncodeToString(uid), Timestamp: &msg.Timestamp, Tag: j.tagName(msg.Tag), Text: msg.Msg, Due: optionalTime(msg.Due)}
+	if msg.Priority != messages.NORMAL {
+		e.Priority = messages.PriorityToStr[msg.Priority]
+	}
+	if msg.Git.Repo != "" {
+		e.Git = &msg.Git
+	}
+	e.Private = msg.Private
+	if err := j.write(e); err != nil {
+		return err
+	}
+	j.uids[msg.ID], j.ids[e.UID] = e.UID, msg.ID
+	return nil
+}
+
+// apply replays an event into j.Memory
+func (j *JSONL) apply(e event) error {
+	if e.UID != "" && e.Op != opAdd {
+		id, ok := j.ids[e.UID]
+		if !ok {
+			return fmt.Errorf("invalid %s, unknown message %s", e.Op, e.UID)
+		}
+		e.ID = id
+	}
+	switch e.Op {
+	case opAdd:
+		tag, err := j.tagID(e.Tag)
+		if err != nil {
+			return err
+		}
+		if e.Timestamp == nil {
+			return fmt.Errorf("invalid add, a timestamp is required")
+		}
+		msg := messages.Message{Timestamp: *e.Timestamp, Tag: tag, Msg: e.Text, Priority: messages.StrToPriority[e.Priority], Private: e.Private}
+		if e.Due != nil {
+			msg.Due = *e.Due
+		}
+		if e.Git != nil {
+			msg.Git = *e.Git
+		}
+		// ids collide when logs written on two git branches are merged,
+		// the later message gets the next free id and its events find it by uid
+		j.lastID = max(j.lastID, e.ID-1)
+		added, err := j.Memory.AddMessage(msg)
+		if err == nil && e.UID != "" {
+			j.uids[added.ID], j.ids[e.UID] = e.UID, added.ID
+		}
+		return err
+	case opEdit:
+		return j.Memory.EditMessage(e.ID, e.Text)
+	case opRetag:
+		tag, err := j.tagID(e.Tag)
+		if err != nil {
+			return err
+		}
+		return j.Memory.ChangeTag(e.ID, tag)
+	case opRetime:
+		if e.Timestamp == nil {
+			return fmt.Errorf("invalid retime, a timestamp is required")
+		}
+		return j.Memory.ChangeTimestamp(e.ID, *e.Timestamp)
+	case opStatus:
+		for status, name := range messages.StatusToStr {
+			if name == e.Status {
+				var at time.Time
+				if e.At != nil {
+					at = *e.At
+				}
+				return j.Memory.SetStatus([]int{e.ID}, status, at)
+			}
+		}
+		return fmt.Errorf("unknown status %q", e.Status)
+	case opPrivate, opPublic:
+		return j.Memory.SetPrivate([]int{e.ID}, e.Op == opPrivate)
+	case opDelete:
+		return j.Memory.DeleteMessages([]int{e.ID})
+	case opAddTag:
+		_, err := j.Memory.AddTag(messages.TagDef{Name: e.Tag, Bg: e.Bg, Fg: e.Fg})
+		return err
+	case opRenameTag:
+		tag, err := j.tagID(e.Tag)
+		if err != nil {
+			return err
+		}
+		return j.Memory.RenameTag(tag, e.Name)
+	case opRemoveTag:
+		tag, err := j.tagID(e.Tag)
+		if err != nil {
+			return err
+		}
+		reassign := messages.ANYTAG
+		if e.Reassign != "" {
+			if reassign, err = j.tagID(e.Reassign); err != nil {
+				return err
+			}
+		}
+		return j.Memory.RemoveTag(tag, reassign)
+	case opSet:
+		return j.Memory.SetSetting(e.Key, e.Value)
+	}
+	return fmt.Errorf("unknown op %q", e.Op)
+}
+
+// change applies e and appends it to the log
+func (j *JSONL) change(e event) error {
+	return j.locked(func() error { return j.commit(e) })
+}
+
+// commit applies e and appends it, only call it while locked
+func (j *JSONL) commit(e event) error {
+	e.UID = j.uids[e.ID]
+	if err := j.apply(e); err != nil {
+		return err
+	}
+	return j.write(e)
+}
+
+// changeAll applies and logs e for each of ids once all of them are found
+func (j *JSONL) changeAll(ids []int, e event) error {
+	return j.locked(func() error {
+		for _, id := range ids {
+			if _, err := j.index(id); err != nil {
+				return err
+			}
+		}
+		for _, id := range ids {
+			e.ID = id
+			if err := j.commit(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (j *JSONL) AddMessage(msg messages.Message) (added messages.Message, err error) {
+	err = j.locked(func() error {
+		if added, err = j.Memory.AddMessage(msg); err != nil {
+			return err
+		}
+		return j.logAdd(added)
+	})
+	return added, err
+}
+
+func (j *JSONL) EditMessage(id int, text string) error {
+	return j.change(event{Op: opEdit, ID: id, Text: text})
+}
+
+// ChangeTag, RenameTag and RemoveTag look tags up after the replay, another mindtick may have just added them
+func (j *JSONL) ChangeTag(id int, tag messages.Tag) error {
+	return j.locked(func() error {
+		if _, ok := j.tagIndex(tag); !ok {
+			return fmt.Errorf("unable to update tag: unknown tag %d", tag)
+		}
+		return j.commit(event{Op: opRetag, ID: id, Tag: j.tagName(tag)})
+	})
+}
+
+func (j *JSONL) ChangeTimestamp(id int, timestamp time.Time) error {
+	return j.change(event{Op: opRetime, ID: id, Timestamp: &timestamp})
+}
+
+func (j *JSONL) SetStatus(ids []int, status messages.Status, at time.Time) error {
+	e := event{Op: opStatus, Status: messages.StatusToStr[status]}
+	if status != messages.OPEN {
+		e.At = optionalTime(at)
+	}
+	return j.changeAll(ids, e)
+}
+
+func (j *JSONL) SetPrivate(ids []int, private bool) error {
+	if private {
+		return j.changeAll(ids, event{Op: opPrivate})
+	}
+	return j.changeAll(ids, event{Op: opPublic})
+}
+
+func (j *JSONL) DeleteMessages(ids []int) error {
+	return j.changeAll(ids, event{Op: opDelete})
+}
+
+func (j *JSONL) AddTag(def messages.TagDef) (added messages.TagDef, err error) {
+	err = j.locked(func() error {
+		if added, err = j.Memory.AddTag(def); err != nil {
+			return err
+		}
+		return j.write(event{Op: opAddTag, Tag: added.Name, Bg: added.Bg, Fg: added.Fg})
+	})
+	return added, err
+}
+
+func (j *JSONL) RenameTag(tag messages.Tag, name string) error {
+	return j.locked(func() error {
+		if _, ok := j.tagIndex(tag); !ok {
+			return fmt.Errorf("unable to rename tag: unknown tag %d", tag)
+		}
+		return j.commit(event{Op: opRenameTag, Tag: j.tagName(tag), Name: name})
+	})
+}
+
+func (j *JSONL) RemoveTag(tag messages.Tag, reassign messages.Tag) error {
+	return j.locked(func() error {
+		if _, ok := j.tagIndex(tag); !ok {
+			return fmt.Errorf("unable to remove tag: unknown tag %d", tag)
+		}
+		e := event{Op: opRemoveTag, Tag: j.tagName(tag)}
+		if reassign != messages.ANYTAG {
+			if e.Reassign = j.tagName(reassign); e.Reassign == "" {
+				return fmt.Errorf("unable to remove tag: unknown tag %d", reassign)
+			}
+		}
+		return j.commit(e)
+	})
+}
+
+func (j *JSONL) SetSetting(key, value string) error {
+	return j.change(event{Op: opSet, Key: key, Value: value})
+}
+
+// Import imports into j.Memory, then logs the created tags and added messages
+func (j *JSONL) Import(entries []Imported, createTags, dryRun bool) (result ImportResult, err error) {
+	err = j.locked(func() error {
+		if result, err = j.Memory.Import(entries, createTags, dryRun); err != nil || dryRun {
+			return err
+		}
+		for _, name := range result.NewTags {
+			if err := j.write(event{Op: opAddTag, Tag: name, Bg: importedTagBg, Fg: importedTagFg}); err != nil {
+				return err
+			}
+		}
+		for _, msg := range result.Added {
+			if err := j.logAdd(msg); err != nil {
+				return err
+			}
+			if msg.Status != messages.OPEN {
+				e := event{Op: opStatus, ID: msg.ID, UID: j.uids[msg.ID], Status: messages.StatusToStr[msg.Status], At: optionalTime(msg.CompletedAt)}
+				if err := j.write(e); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package store
+
+import "os"
+
+// lockFile is a no-op without flock, a single mindtick should write a log at a time
+func lockFile(*os.File) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
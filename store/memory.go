@@ -0,0 +1,322 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Memory is a Store that lives as long as the process, for tests.
+// It is also the state a JSONL log is replayed into.
+type Memory struct {
+	msgs     []messages.Message // ordered by id
+	tags     []messages.TagDef  // ordered by id
+	settings map[string]string
+	lastID   int
+	lastTag  messages.Tag
+}
+
+// NewMemory returns an empty store with the builtin tags
+func NewMemory() *Memory {
+	m := &Memory{
+		tags:     slices.Clone(messages.BuiltinTags),
+		settings: map[string]string{},
+	}
+	for _, def := range m.tags {
+		m.lastTag = max(m.lastTag, def.ID)
+	}
+	messages.SetTags(slices.Clone(m.tags))
+	return m
+}
+
+func (m *Memory) Path() string {
+	return ""
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// clone is a deep copy of m, dry runs happen on a clone
+func (m *Memory) clone() *Memory {
+	c := *m
+	c.msgs = slices.Clone(m.msgs)
+	c.tags = slices.Clone(m.tags)
+	c.settings = maps.Clone(m.settings)
+	return &c
+}
+
+func (m *Memory) AddMessage(msg messages.Message) (messages.Message, error) {
+	if _, ok := m.tagIndex(msg.Tag); !ok {
+		return msg, fmt.Errorf("unable to add message: unknown tag %d", msg.Tag)
+	}
+	m.lastID++
+	msg.ID = m.lastID
+	m.msgs = append(m.msgs, msg)
+	return msg, nil
+}
+
+func (m *Memory) Messages(f Filter) ([]messages.Message, error) {
+	var query searchExpr
+	if f.Query != "" {
+		var err error
+		if query, err = parseSearch(f.Query); err != nil {
+			return nil, invalidSearchErr(f.Query, err)
+		}
+	}
+
+	var msgs []messages.Message
+	for _, msg := range m.msgs {
+		switch {
+		case f.Tag != messages.ANYTAG && msg.Tag != f.Tag,
+			!f.Range.Start.IsZero() && msg.Timestamp.Before(f.Range.Start),
+			!f.Range.End.IsZero() && !msg.Timestamp.Before(f.Range.End),
+			len(f.Statuses) > 0 && !slices.Contains(f.Statuses, msg.Status),
+			f.Branch != "" && msg.Git.Branch != f.Branch,
+			f.IDs != (IDRange{}) && (msg.ID < f.IDs.From || msg.ID > f.IDs.To),
+			query != nil && !matchesSearch(query, msg.Msg):
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	slices.SortStableFunc(msgs, func(a, b messages.Message) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return msgs, nil
+}
+
+func (m *Memory) index(id int) (int, error) {
+	i, ok := slices.BinarySearchFunc(m.msgs, id, func(msg messages.Message, id int) int {
+		return cmp.Compare(msg.ID, id)
+	})
+	if !ok {
+		return 0, noMessageErr(id)
+	}
+	return i, nil
+}
+
+func (m *Memory) Message(id int) (messages.Message, error) {
+	i, err := m.index(id)
+	if err != nil {
+		return messages.Message{}, err
+	}
+	return m.msgs[i], nil
+}
+
+// update applies change to the message with id
+func (m *Memory) update(id int, change func(msg *messages.Message)) error {
+	i, err := m.index(id)
+	if err != nil {
+		return err
+	}
+	change(&m.msgs[i])
+	return nil
+}
+
+// updateAll applies change to the messages with ids once all of them are found
+func (m *Memory) updateAll(ids []int, change func(msg *messages.Message)) error {
+	for _, id := range ids {
+		if _, err := m.index(id); err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		i, _ := m.index(id)
+		change(&m.msgs[i])
+	}
+	return nil
+}
+
+func (m *Memory) EditMessage(id int, text string) error {
+	return m.update(id, func(msg *messages.Message) { msg.Msg = text })
+}
+
+func (m *Memory) ChangeTag(id int, tag messages.Tag) error {
+	if _, ok := m.tagIndex(tag); !ok {
+		return fmt.Errorf("unable to update tag: unknown tag %d", tag)
+	}
+	return m.update(id, func(msg *messages.Message) { msg.Tag = tag })
+}
+
+func (m *Memory) ChangeTimestamp(id int, timestamp time.Time) error {
+	return m.update(id, func(msg *messages.Message) { msg.Timestamp = timestamp })
+}
+
+func (m *Memory) SetStatus(ids []int, status messages.Status, at time.Time) error {
+	return m.updateAll(ids, func(msg *messages.Message) {
+		msg.Status, msg.CompletedAt = status, time.Time{}
+		if status != messages.OPEN {
+			msg.CompletedAt = at
+		}
+	})
+}
+
+func (m *Memory) SetPrivate(ids []int, private bool) error {
+	return m.updateAll(ids, func(msg *messages.Message) { msg.Private = private })
+}
+
+func (m *Memory) DeleteMessages(ids []int) error {
+	deleted := map[int]bool{}
+	for _, id := range ids {
+		if _, err := m.index(id); err != nil {
+			return err
+		}
+		deleted[id] = true
+	}
+	m.msgs = slices.DeleteFunc(m.msgs, func(msg messages.Message) bool { return deleted[msg.ID] })
+	return nil
+}
+
+func (m *Memory) Overdue(now time.Time) (int, error) {
+	var count int
+	for _, msg := range m.msgs {
+		if msg.Tag == messages.TASK && msg.Status == messages.OPEN && !msg.Due.IsZero() && !msg.Due.After(now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *Memory) Tags() ([]messages.TagDef, error) {
+	return slices.Clone(m.tags), nil
+}
+
+func (m *Memory) tagIndex(tag messages.Tag) (int, bool) {
+	return slices.BinarySearchFunc(m.tags, tag, func(def messages.TagDef, tag messages.Tag) int {
+		return cmp.Compare(def.ID, tag)
+	})
+}
+
+// tagNamed finds a tag case insensitively
+func (m *Memory) tagNamed(name string) (messages.TagDef, bool) {
+	for _, def := range m.tags {
+		if strings.EqualFold(def.Name, name) {
+			return def, true
+		}
+	}
+	return messages.TagDef{}, false
+}
+
+func (m *Memory) AddTag(def messages.TagDef) (messages.TagDef, error) {
+	if _, ok := m.tagNamed(def.Name); ok {
+		return def, fmt.Errorf("tag %s already exists", messages.ColorizeStr(def.Name, messages.BrightPurple))
+	}
+	m.lastTag++
+	def.ID = m.lastTag
+	m.tags = append(m.tags, def)
+	messages.SetTags(slices.Clone(m.tags))
+	return def, nil
+}
+
+func (m *Memory) RenameTag(tag messages.Tag, name string) error {
+	if def, ok := m.tagNamed(name); ok && def.ID != tag {
+		return fmt.Errorf("tag %s already exists", messages.ColorizeStr(name, messages.BrightPurple))
+	}
+	i, ok := m.tagIndex(tag)
+	if !ok {
+		return fmt.Errorf("unable to rename tag: unknown tag %d", tag)
+	}
+	m.tags[i].Name = name
+	messages.SetTags(slices.Clone(m.tags))
+	return nil
+}
+
+func (m *Memory) TagUsage(tag messages.Tag) (int, error) {
+	var count int
+	for _, msg := range m.msgs {
+		if msg.Tag == tag {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *Memory) RemoveTag(tag messages.Tag, reassign messages.Tag) error {
+	i, ok := m.tagIndex(tag)
+	if !ok {
+		return fmt.Errorf("unable to remove tag: unknown tag %d", tag)
+	}
+	if reassign != messages.ANYTAG {
+		if _, ok := m.tagIndex(reassign); !ok {
+			return fmt.Errorf("unable to remove tag: unknown tag %d", reassign)
+		}
+	}
+
+	m.msgs = slices.DeleteFunc(m.msgs, func(msg messages.Message) bool {
+		return msg.Tag == tag && reassign == messages.ANYTAG
+	})
+	for i := range m.msgs {
+		if m.msgs[i].Tag == tag {
+			m.msgs[i].Tag = reassign
+		}
+	}
+	m.tags = slices.Delete(m.tags, i, i+1)
+	messages.SetTags(slices.Clone(m.tags))
+	return nil
+}
+
+func (m *Memory) Setting(key string) (string, error) {
+	return m.settings[key], nil
+}
+
+func (m *Memory) SetSetting(key, value string) error {
+	if value == "" {
+		delete(m.settings, key)
+		return nil
+	}
+	m.settings[key] = value
+	return nil
+}
+
+// Import works on a clone that replaces m unless it is a dry run
+func (m *Memory) Import(entries []Imported, createTags, dryRun bool) (ImportResult, error) {
+	var result ImportResult
+	tx := m.clone()
+
+	tagIDs := map[string]messages.Tag{}
+	for _, def := range tx.tags {
+		tagIDs[strings.ToLower(def.Name)] = def.ID
+	}
+	missing, err := missingTags(entries, tagIDs, createTags)
+	if err != nil {
+		return result, err
+	}
+	for _, name := range missing {
+		def, err := tx.AddTag(messages.TagDef{Name: name, Bg: importedTagBg, Fg: importedTagFg})
+		if err != nil {
+			return result, err
+		}
+		tagIDs[name] = def.ID
+		result.NewTags = append(result.NewTags, name)
+	}
+
+	seen := map[duplicateKey]bool{}
+	for _, msg := range tx.msgs {
+		seen[keyOf(msg)] = true
+	}
+	for _, entry := range entries {
+		msg := entry.message(tagIDs[strings.ToLower(entry.Tag)])
+		if seen[keyOf(msg)] {
+			result.Duplicates = append(result.Duplicates, msg)
+			continue
+		}
+		seen[keyOf(msg)] = true
+
+		if msg, err = tx.AddMessage(msg); err != nil {
+			return result, err
+		}
+		result.Added = append(result.Added, msg)
+	}
+
+	// tx.AddTag set the tags of the clone, a dry run keeps them so the preview renders them like SQLite does
+	if dryRun {
+		return result, nil
+	}
+	*m = *tx
+	return result, nil
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// A migration upgrades a store by exactly one schema version.
+// Released migrations must never be edited, append a new one instead.
+type migration struct {
+	name string
+	sql  string
+}
+
+// migrations[i] upgrades a store from version i to version i+1.
+// The version is tracked with PRAGMA user_version, stores created before
+// versioning existed report version 0.
+var migrations = []migration{
+	{
+		name: "create messages",
+		// IF NOT EXISTS because unversioned stores already have this table
+		sql: `CREATE TABLE IF NOT EXISTS messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME,
+			msg TEXT,
+			msgtype INT
+		)`,
+	},
+	{
+		name: "full text search",
+		// external content table, the triggers keep it in sync with messages
+		sql: `CREATE VIRTUAL TABLE messages_fts USING fts5(msg, content='messages', content_rowid='id');
+		CREATE TRIGGER messages_fts_insert AFTER INSERT ON messages BEGIN
+			INSERT INTO messages_fts(rowid, msg) VALUES (new.id, new.msg);
+		END;
+		CREATE TRIGGER messages_fts_delete AFTER DELETE ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, msg) VALUES ('delete', old.id, old.msg);
+		END;
+		CREATE TRIGGER messages_fts_update AFTER UPDATE OF msg ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, msg) VALUES ('delete', old.id, old.msg);
+			INSERT INTO messages_fts(rowid, msg) VALUES (new.id, new.msg);
+		END;
+		INSERT INTO messages_fts(messages_fts) VALUES ('rebuild');`,
+	},
+	{
+		name: "custom tags",
+		// ids match the msgtype values of the tags that used to be hard coded
+		sql: `CREATE TABLE tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+			bg TEXT NOT NULL,
+			fg TEXT NOT NULL
+		);
+		INSERT INTO tags (id, name, bg, fg) VALUES
+			(1, 'win', 'green', 'white'),
+			(2, 'note', 'cyan', 'white'),
+			(3, 'fix', 'bright-yellow', 'black'),
+			(4, 'task', 'bright-purple', 'white'),
+			(5, 'url', 'black', 'blue'),
+			(6, 'work', 'bright-white', 'black'),
+			(7, 'ALERT', 'red', 'white');`,
+	},
+	{
+		name: "task status",
+		sql: `ALTER TABLE messages ADD COLUMN status INT NOT NULL DEFAULT 0;
+		ALTER TABLE messages ADD COLUMN completed_at DATETIME;`,
+	},
+	{
+		name: "due dates and priorities",
+		sql: `ALTER TABLE messages ADD COLUMN due DATETIME;
+		ALTER TABLE messages ADD COLUMN priority INT NOT NULL DEFAULT 0;`,
+	},
+	{
+		name: "settings",
+		sql: `CREATE TABLE settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)`,
+	},
+	{
+		name: "git",
+		// NULL for messages added outside of a git work tree
+		sql: `ALTER TABLE messages ADD COLUMN git_repo TEXT;
+		ALTER TABLE messages ADD COLUMN git_branch TEXT;
+		ALTER TABLE messages ADD COLUMN git_commit TEXT;
+		ALTER TABLE messages ADD COLUMN git_dirty INT NOT NULL DEFAULT 0;
+		CREATE INDEX messages_git_branch ON messages (git_branch);`,
+	},
+	{
+		name: "private messages",
+		sql:  `ALTER TABLE messages ADD COLUMN private INT NOT NULL DEFAULT 0;`,
+	},
+}
+
+// SchemaVersion is the schema version this binary reads and writes
+var SchemaVersion = len(migrations)
+
+func schemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := retry(func() error {
+		return db.QueryRow("PRAGMA user_version").Scan(&version)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to read %s schema version: %v", COLORDBFILENAME, err)
+	}
+	return version, nil
+}
+
+// migrate upgrades db to SchemaVersion, refusing stores written by a newer mindtick
+func migrate(db *sql.DB) error {
+	return migrateTo(db, SchemaVersion)
+}
+
+func migrateTo(db *sql.DB, target int) error {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("%s uses schema version %d but this mindtick only supports up to %d\nupgrade with %s",
+			COLORDBFILENAME, version, SchemaVersion, messages.ColorizeStr("go install github.com/ninesl/mindtick@latest", messages.BrightGreen))
+	}
+
+	for ; version < target; version++ {
+		if err := retry(func() error { return applyMigration(db, version) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMigration runs migrations[version] and bumps user_version in one transaction,
+// sqlite errors are wrapped so migrateTo can retry when another mindtick holds the lock
+func applyMigration(db *sql.DB, version int) error {
+	m := migrations[version]
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to start migration %d (%s): %w", version+1, m.name, err)
+	}
+	defer tx.Rollback()
+
+	// another mindtick opening the store at the same time may have applied it while we waited for the lock
+	var current int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&current); err != nil {
+		return fmt.Errorf("unable to read %s schema version: %w", COLORDBFILENAME, err)
+	}
+	if current > version {
+		return nil
+	}
+
+	if _, err := tx.Exec(m.sql); err != nil {
+		return fmt.Errorf("unable to apply migration %d (%s): %w", version+1, m.name, err)
+	}
+	// PRAGMA does not accept bound parameters
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+		return fmt.Errorf("unable to record migration %d (%s): %w", version+1, m.name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit migration %d (%s): %w", version+1, m.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// legacySchema is what createSchema wrote before stores were versioned
+const legacySchema = `CREATE TABLE IF NOT EXISTS messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME,
+	msg TEXT,
+	msgtype INT
+)`
+
+// fixture writes a store at the given schema version with a few messages in it
+func fixture(t *testing.T, version int, legacy bool) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DBFileName)
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if legacy {
+		if _, err := db.Exec(legacySchema); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := migrateTo(db, version); err != nil {
+		t.Fatal(err)
+	}
+
+	if legacy || version > 0 {
+		for i, tag := range []messages.Tag{messages.WIN, messages.FIX, messages.NOTE} {
+			ts := time.Date(2025, 1, i+1, 9, 0, 0, 0, time.Local)
+			_, err := db.Exec("INSERT INTO messages (timestamp, msg, msgtype) VALUES (?, ?, ?)", ts, fmt.Sprintf("message %d", i), tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return path
+}
+
+func TestMigrateFromEveryVersion(t *testing.T) {
+	type tc struct {
+		version int
+		legacy  bool
+		want    int // messages that survive the upgrade
+	}
+	cases := []tc{{version: 0, want: 0}, {version: 0, legacy: true, want: 3}}
+	for v := 1; v <= SchemaVersion; v++ {
+		cases = append(cases, tc{version: v, want: 3})
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("v%d legacy=%v", c.version, c.legacy), func(t *testing.T) {
+			db, err := OpenSQLite(fixture(t, c.version, c.legacy))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			version, err := schemaVersion(db.db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != SchemaVersion {
+				t.Errorf("expected schema version %d, got %d", SchemaVersion, version)
+			}
+
+			msgs, err := db.Messages(Filter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(msgs) != c.want {
+				t.Fatalf("expected %d messages after upgrade, got %d", c.want, len(msgs))
+			}
+			if c.want > 0 && (msgs[0].Msg != "message 0" || msgs[0].Tag != messages.WIN) {
+				t.Errorf("first message changed during upgrade: %+v", msgs[0])
+			}
+
+			if _, err := db.AddMessage(messages.Message{Timestamp: time.Now(), Msg: "after upgrade", Tag: messages.TASK}); err != nil {
+				t.Errorf("unable to write to upgraded store: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateRefusesNewerStore(t *testing.T) {
+	path := fixture(t, SchemaVersion, false)
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion+1)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if db, err := OpenSQLite(path); err == nil {
+		db.Close()
+		t.Fatal("expected an error opening a store from a newer mindtick")
+	}
+}
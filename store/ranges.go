@@ -0,0 +1,402 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Range is the span [Start, End) of messages to show,
+// a zero Start or End leaves that side unbounded
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+var ANYTIME = Range{}
+
+func (r Range) String() string {
+	const layout = "Jan 02, 2006 03:04 PM"
+	switch {
+	case r.Start.IsZero() && r.End.IsZero():
+		return "anytime"
+	case r.End.IsZero():
+		return "since " + r.Start.Format(layout)
+	case r.Start.IsZero():
+		return "until " + r.End.Format(layout)
+	}
+	return r.Start.Format(layout) + " to " + r.End.Format(layout)
+}
+
+// named ranges run from their start until now
+var (
+	namedRanges = map[string]func(now time.Time) time.Time{
+		"today": func(now time.Time) time.Time {
+			return startOfDay(now)
+		},
+		"yesterday": func(now time.Time) time.Time {
+			return startOfDay(now.AddDate(0, 0, -1))
+		},
+		"week": func(now time.Time) time.Time {
+			return startOfDay(now.AddDate(0, 0, -7))
+		},
+		"month": func(now time.Time) time.Time {
+			return startOfDay(now.AddDate(0, -1, 0))
+		},
+	}
+	RangeNames = []string{"today", "yesterday", "week", "month"}
+
+	// RangeExamples are shown by `mindtick ranges`
+	RangeExamples = []string{
+		"2026-03-14", "2026-03-01..2026-03-31", "since 2026-01-01", "until friday",
+		"last 10d", "2026-03", "q3", "2026-W12", "2025",
+	}
+)
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func day(t time.Time) Range {
+	start := startOfDay(t)
+	return Range{Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+var (
+	weekdays = map[string]time.Weekday{
+		"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+		"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+		"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+		"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	}
+
+	isoWeekRe  = regexp.MustCompile(`^(\d{4})-w(\d{1,2})$`)
+	quarterRe  = regexp.MustCompile(`^(?:(\d{4})-)?q([1-4])$`)
+	durationRe = regexp.MustCompile(`^(\d+)\s*(h|hours?|d|days?|w|weeks?|mo|months?|y|years?)$`)
+)
+
+// lastWeekday is the most recent wd on or before now
+func lastWeekday(now time.Time, wd time.Weekday) time.Time {
+	return now.AddDate(0, 0, -((int(now.Weekday()) - int(wd) + 7) % 7))
+}
+
+// nextWeekday is the first wd on or after now
+func nextWeekday(now time.Time, wd time.Weekday) time.Time {
+	return now.AddDate(0, 0, (int(wd)-int(now.Weekday())+7)%7)
+}
+
+// isoWeekStart is the monday starting ISO week `week` of `year`
+func isoWeekStart(year, week int, loc *time.Location) time.Time {
+	// january 4th is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, (week-1)*7)
+}
+
+// parseSpan parses an expression naming one calendar span:
+// a day (2026-03-14, today, yesterday, friday), an ISO week (2026-W12),
+// a month (2026-03), a quarter (q3, 2026-q3) or a year (2026).
+// Weekdays resolve to the most recent one, today included.
+func parseSpan(expr string, now time.Time) (Range, error) {
+	loc := now.Location()
+	switch expr {
+	case "today":
+		return day(now), nil
+	case "yesterday":
+		return day(now.AddDate(0, 0, -1)), nil
+	case "tomorrow":
+		return day(now.AddDate(0, 0, 1)), nil
+	}
+	if wd, ok := weekdays[expr]; ok {
+		return day(lastWeekday(now, wd)), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", expr, loc); err == nil {
+		return day(t), nil
+	}
+	if t, err := time.ParseInLocation("2006-01", expr, loc); err == nil {
+		return Range{Start: t, End: t.AddDate(0, 1, 0)}, nil
+	}
+	if t, err := time.ParseInLocation("2006", expr, loc); err == nil {
+		return Range{Start: t, End: t.AddDate(1, 0, 0)}, nil
+	}
+
+	if m := isoWeekRe.FindStringSubmatch(expr); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		if week < 1 || week > 53 {
+			return Range{}, fmt.Errorf("invalid week %s", messages.ColorizeStr(expr, messages.BrightPurple))
+		}
+		start := isoWeekStart(year, week, loc)
+		return Range{Start: start, End: start.AddDate(0, 0, 7)}, nil
+	}
+	if m := quarterRe.FindStringSubmatch(expr); m != nil {
+		year := now.Year()
+		if m[1] != "" {
+			year, _ = strconv.Atoi(m[1])
+		}
+		quarter, _ := strconv.Atoi(m[2])
+		start := time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, loc)
+		return Range{Start: start, End: start.AddDate(0, 3, 0)}, nil
+	}
+
+	return Range{}, fmt.Errorf("unknown range %s, see %s", messages.ColorizeStr(expr, messages.BrightPurple), messages.ColorizeStr("mindtick ranges", messages.BrightGreen))
+}
+
+// parseAgo returns now minus a duration like 10d, 3 weeks or 12h.
+// Day based units are rounded down to the start of the day.
+func parseAgo(expr string, now time.Time) (time.Time, bool) {
+	m := durationRe.FindStringSubmatch(expr)
+	if m == nil {
+		return time.Time{}, false
+	}
+	n, _ := strconv.Atoi(m[1])
+
+	switch m[2][0] {
+	case 'h':
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case 'd':
+		return startOfDay(now.AddDate(0, 0, -n)), true
+	case 'w':
+		return startOfDay(now.AddDate(0, 0, -7*n)), true
+	case 'm':
+		return startOfDay(now.AddDate(0, -n, 0)), true
+	}
+	return startOfDay(now.AddDate(-n, 0, 0)), true
+}
+
+// parseIn returns now plus a duration like 3d, 2 weeks or 12h.
+// Day based units run until the end of that day.
+func parseIn(expr string, now time.Time) (time.Time, bool) {
+	m := durationRe.FindStringSubmatch(expr)
+	if m == nil {
+		return time.Time{}, false
+	}
+	n, _ := strconv.Atoi(m[1])
+
+	switch m[2][0] {
+	case 'h':
+		return now.Add(time.Duration(n) * time.Hour), true
+	case 'd':
+		return day(now.AddDate(0, 0, n)).End, true
+	case 'w':
+		return day(now.AddDate(0, 0, 7*n)).End, true
+	case 'm':
+		return day(now.AddDate(0, n, 0)).End, true
+	}
+	return day(now.AddDate(n, 0, 0)).End, true
+}
+
+// ParseDue parses when a task is due relative to now, the result is the
+// instant the task becomes overdue:
+//
+//	today, tomorrow, fri        by the end of that day, weekdays are the next one, today included
+//	eow, eom                    by the end of this week's friday or this month
+//	3d, in 2 weeks, 12h, 2mo    from now, day based units until the end of that day
+//	2026-03-14, 2026-W12, q3    by the end of that day, week, month, quarter or year
+func ParseDue(expr string, now time.Time) (time.Time, error) {
+	expr = strings.ToLower(strings.Join(strings.Fields(expr), " "))
+	expr = strings.TrimPrefix(expr, "in ")
+
+	if wd, ok := weekdays[expr]; ok {
+		return day(nextWeekday(now, wd)).End, nil
+	}
+	switch expr {
+	case "eow", "end of week":
+		return day(nextWeekday(now, time.Friday)).End, nil
+	case "eom", "end of month":
+		return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	if due, ok := parseIn(expr, now); ok {
+		return due, nil
+	}
+
+	span, err := parseSpan(expr, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown due date %s, try %s", messages.ColorizeStr(expr, messages.BrightPurple), messages.ColorizeStr("fri, tomorrow, 3d or 2026-03-14", messages.BrightGreen))
+	}
+	return span.End, nil
+}
+
+// ParseRange parses a `mindtick view` range expression relative to now:
+//
+//	today, yesterday, week, month  since that day until now
+//	2026-03-14, friday, 2026-W12    a single day, week, month (2026-03), quarter (q3) or year (2026)
+//	2026-03-01..2026-03-31          from the start of one span to the end of another, either side may be empty
+//	since 2026-01-01, until friday  open ended spans
+//	last 10d                        the last n hours, days, weeks, months or years
+func ParseRange(expr string, now time.Time) (Range, error) {
+	expr = strings.ToLower(strings.Join(strings.Fields(expr), " "))
+	if expr == "" || expr == "anytime" {
+		return ANYTIME, nil
+	}
+	if start, ok := namedRanges[expr]; ok {
+		return Range{Start: start(now)}, nil
+	}
+
+	if from, to, ok := strings.Cut(expr, ".."); ok {
+		var r Range
+		if from = strings.TrimSpace(from); from != "" {
+			span, err := parseSpan(from, now)
+			if err != nil {
+				return Range{}, err
+			}
+			r.Start = span.Start
+		}
+		if to = strings.TrimSpace(to); to != "" {
+			span, err := parseSpan(to, now)
+			if err != nil {
+				return Range{}, err
+			}
+			r.End = span.End
+		}
+		if !r.Start.IsZero() && !r.End.IsZero() && !r.Start.Before(r.End) {
+			return Range{}, fmt.Errorf("range %s ends before it starts", messages.ColorizeStr(expr, messages.BrightPurple))
+		}
+		return r, nil
+	}
+
+	if rest, ok := strings.CutPrefix(expr, "since "); ok {
+		span, err := parseSpan(rest, now)
+		return Range{Start: span.Start}, err
+	}
+	if rest, ok := strings.CutPrefix(expr, "until "); ok {
+		span, err := parseSpan(rest, now)
+		return Range{End: span.End}, err
+	}
+	if rest, ok := strings.CutPrefix(expr, "last "); ok {
+		if rest == "week" || rest == "month" || rest == "year" {
+			rest = "1 " + rest
+		}
+		start, ok := parseAgo(rest, now)
+		if !ok {
+			return Range{}, fmt.Errorf("unknown duration %s, try %s", messages.ColorizeStr(rest, messages.BrightPurple), messages.ColorizeStr("last 10d", messages.BrightGreen))
+		}
+		return Range{Start: start}, nil
+	}
+
+	return parseSpan(expr, now)
+}
+
+var (
+	agoRe       = regexp.MustCompile(`^(\d+)\s*(mins?|minutes?|h|hrs?|hours?|d|days?|w|weeks?)\s+ago$`)
+	clockRe     = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	timeLayouts = []string{"2006-01-02 15:04", "2006-01-02t15:04", "2006-01-02 15:04:05", "2006-01-02t15:04:05"} // lowercased like the expression
+)
+
+// parseClock parses a time of day like 4pm, 4:30 pm, 16:30, noon or midnight
+func parseClock(expr string) (hour, min int, ok bool) {
+	switch expr {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+	m := clockRe.FindStringSubmatch(expr)
+	if m == nil {
+		return 0, 0, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		min, _ = strconv.Atoi(m[2])
+	}
+	switch {
+	case m[3] == "" && m[2] == "": // a bare number is a date or a year, not a time
+		return 0, 0, false
+	case m[3] != "" && (hour < 1 || hour > 12):
+		return 0, 0, false
+	case m[3] == "am" && hour == 12:
+		hour = 0
+	case m[3] == "pm" && hour != 12:
+		hour += 12
+	}
+	if hour > 23 || min > 59 {
+		return 0, 0, false
+	}
+	return hour, min, true
+}
+
+// parseDay parses the day part of a timestamp, weekdays are the most recent one,
+// today included, and `last monday` is the one before today
+func parseDay(expr string, now time.Time) (time.Time, bool) {
+	if rest, ok := strings.CutPrefix(expr, "last "); ok {
+		if wd, ok := weekdays[rest]; ok {
+			return lastWeekday(now.AddDate(0, 0, -1), wd), true
+		}
+		return time.Time{}, false
+	}
+	if _, ok := weekdays[expr]; ok || expr == "today" || expr == "yesterday" {
+		span, _ := parseSpan(expr, now)
+		return span.Start, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02", expr, now.Location()); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// ParseTime parses when a message happened relative to now:
+//
+//	2h ago, 30 minutes ago, 3 days ago  exactly that long before now
+//	yesterday 4pm, last monday noon      a day and a time of day
+//	2026-10-01 09:30                     a date and a 24 hour time
+//	4:30pm, noon                         the most recent time it was that time of day
+//	yesterday, friday, 2026-10-01        that day at the current time of day
+func ParseTime(expr string, now time.Time) (time.Time, error) {
+	expr = strings.ToLower(strings.Join(strings.Fields(expr), " "))
+	if expr == "now" {
+		return now, nil
+	}
+
+	if m := agoRe.FindStringSubmatch(expr); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2][0] {
+		case 'm':
+			return now.Add(-time.Duration(n) * time.Minute), nil
+		case 'h':
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case 'd':
+			return now.AddDate(0, 0, -n), nil
+		}
+		return now.AddDate(0, 0, -7*n), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, expr, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	if hour, min, ok := parseClock(expr); ok {
+		t := time.Date(now.Year(), now.Month(), now.Day(), hour, min, 0, 0, now.Location())
+		if t.After(now) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, nil
+	}
+
+	// the time of day is the last word, or the last two for `4 pm`
+	dayExpr, clock := expr, ""
+	words := strings.Fields(expr)
+	for n := 2; n >= 1; n-- {
+		if len(words) > n {
+			if _, _, ok := parseClock(strings.Join(words[len(words)-n:], " ")); ok {
+				dayExpr, clock = strings.Join(words[:len(words)-n], " "), strings.Join(words[len(words)-n:], " ")
+				break
+			}
+		}
+	}
+	date, ok := parseDay(dayExpr, now)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown time %s, try %s", messages.ColorizeStr(expr, messages.BrightPurple), messages.ColorizeStr(`"yesterday 4pm", "2h ago" or "2026-10-01 09:30"`, messages.BrightGreen))
+	}
+	hour, min, sec := now.Clock()
+	if clock != "" {
+		hour, min, _ = parseClock(clock)
+		sec = 0
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, min, sec, 0, now.Location()), nil
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, 3, 18, 15, 30, 0, 0, time.Local)
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+
+	cases := []struct {
+		expr       string
+		start, end time.Time
+	}{
+		{"", time.Time{}, time.Time{}},
+		{"today", date(2026, 3, 18), time.Time{}},
+		{"yesterday", date(2026, 3, 17), time.Time{}},
+		{"week", date(2026, 3, 11), time.Time{}},
+		{"month", date(2026, 2, 18), time.Time{}},
+		{"2026-03-14", date(2026, 3, 14), date(2026, 3, 15)},
+		{"2026-03-01..2026-03-31", date(2026, 3, 1), date(2026, 4, 1)},
+		{"2026-03-01..", date(2026, 3, 1), time.Time{}},
+		{"since 2026-01-01", date(2026, 1, 1), time.Time{}},
+		{"until friday", time.Time{}, date(2026, 3, 14)},
+		{"until wednesday", time.Time{}, date(2026, 3, 19)},
+		{"last 10d", date(2026, 3, 8), time.Time{}},
+		{"last  2 weeks", date(2026, 3, 4), time.Time{}},
+		{"last 3h", now.Add(-3 * time.Hour), time.Time{}},
+		{"last 2mo", date(2026, 1, 18), time.Time{}},
+		{"Q3", date(2026, 7, 1), date(2026, 10, 1)},
+		{"2025-q4", date(2025, 10, 1), date(2026, 1, 1)},
+		{"2026-W12", date(2026, 3, 16), date(2026, 3, 23)},
+		{"2026-w01", date(2025, 12, 29), date(2026, 1, 5)},
+		{"2026-02", date(2026, 2, 1), date(2026, 3, 1)},
+		{"2025", date(2025, 1, 1), date(2026, 1, 1)},
+	}
+	for _, c := range cases {
+		r, err := ParseRange(c.expr, now)
+		if err != nil {
+			t.Errorf("ParseRange(%q): %v", c.expr, err)
+			continue
+		}
+		if !r.Start.Equal(c.start) || !r.End.Equal(c.end) {
+			t.Errorf("ParseRange(%q) = %v, want %v", c.expr, r, Range{Start: c.start, End: c.end})
+		}
+	}
+
+	for _, expr := range []string{"someday", "2026-13-01", "2026-W60", "last forever", "last 30m", "2026-03-31..2026-03-01"} {
+		if _, err := ParseRange(expr, now); err == nil {
+			t.Errorf("ParseRange(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestParseDue(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, 3, 18, 15, 30, 0, 0, time.Local)
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+
+	cases := []struct {
+		expr string
+		due  time.Time
+	}{
+		{"today", date(2026, 3, 19)},
+		{"tomorrow", date(2026, 3, 20)},
+		{"fri", date(2026, 3, 21)},
+		{"Monday", date(2026, 3, 24)},
+		{"wed", date(2026, 3, 19)},
+		{"eow", date(2026, 3, 21)},
+		{"eom", date(2026, 4, 1)},
+		{"3d", date(2026, 3, 22)},
+		{"in 2 weeks", date(2026, 4, 2)},
+		{"in 12h", now.Add(12 * time.Hour)},
+		{"2mo", date(2026, 5, 19)},
+		{"in 1 month", date(2026, 4, 19)},
+		{"2026-04-01", date(2026, 4, 2)},
+		{"2026-W13", date(2026, 3, 30)},
+		{"q2", date(2026, 7, 1)},
+	}
+	for _, c := range cases {
+		due, err := ParseDue(c.expr, now)
+		if err != nil {
+			t.Errorf("ParseDue(%q): %v", c.expr, err)
+			continue
+		}
+		if !due.Equal(c.due) {
+			t.Errorf("ParseDue(%q) = %v, want %v", c.expr, due, c.due)
+		}
+	}
+
+	for _, expr := range []string{"someday", "in forever", "2026-13-01", "30m"} {
+		if _, err := ParseDue(expr, now); err == nil {
+			t.Errorf("ParseDue(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, 3, 18, 15, 30, 0, 0, time.Local)
+	at := func(m time.Month, d, hour, min int) time.Time {
+		return time.Date(2026, m, d, hour, min, 0, 0, time.Local)
+	}
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"now", now},
+		{"2h ago", at(3, 18, 13, 30)},
+		{"45 minutes ago", at(3, 18, 14, 45)},
+		{"3 days ago", at(3, 15, 15, 30)},
+		{"yesterday 4pm", at(3, 17, 16, 0)},
+		{"yesterday 4 pm", at(3, 17, 16, 0)},
+		{"last monday noon", at(3, 16, 12, 0)},
+		{"last wednesday 9:15am", at(3, 11, 9, 15)},
+		{"wednesday 8am", at(3, 18, 8, 0)},
+		{"2026-10-01 09:30", at(10, 1, 9, 30)},
+		{"2026-03-01T23:05", at(3, 1, 23, 5)},
+		{"noon", at(3, 18, 12, 0)},
+		{"4:45pm", at(3, 17, 16, 45)},
+		{"12am", at(3, 18, 0, 0)},
+		{"yesterday", at(3, 17, 15, 30)},
+		{"2026-03-02 midnight", at(3, 2, 0, 0)},
+	}
+	for _, c := range cases {
+		got, err := ParseTime(c.expr, now)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", c.expr, err)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", c.expr, got, c.want)
+		}
+	}
+
+	for _, expr := range []string{"someday", "13pm", "yesterday 25:00", "last year", "4", "2 months ago"} {
+		if _, err := ParseTime(expr, now); err == nil {
+			t.Errorf("ParseTime(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestWorkWeek(t *testing.T) {
+	date := func(d int) time.Time {
+		return time.Date(2026, 3, d, 9, 0, 0, 0, time.Local) // the 16th is a monday
+	}
+
+	cases := []struct {
+		week string
+		now  time.Time
+		want time.Time
+	}{
+		{"mon-fri", date(18), date(17)},
+		{"mon-fri", date(16), date(13)},
+		{"mon-fri", date(15), date(13)},
+		{"mon-fri", date(14), date(13)},
+		{"sun-thu", date(15), date(12)},
+		{"Sun-Thu", date(16), date(15)},
+		{"mon,wed,fri", date(19), date(18)},
+		{"fri-mon", date(17), date(16)},
+		{"fri-mon", date(20), date(16)},
+	}
+	for _, c := range cases {
+		w, err := ParseWorkWeek(c.week)
+		if err != nil {
+			t.Errorf("ParseWorkWeek(%q): %v", c.week, err)
+			continue
+		}
+		want := day(c.want)
+		if got := w.LastWorkday(c.now); got != want {
+			t.Errorf("%s LastWorkday(%s) = %v, want %v", c.week, c.now.Format("Mon Jan 02"), got, want)
+		}
+	}
+
+	for _, expr := range []string{"", "mon-", "weekdays", "mon-fri,someday"} {
+		if _, err := ParseWorkWeek(expr); err == nil {
+			t.Errorf("ParseWorkWeek(%q) expected an error", expr)
+		}
+	}
+}
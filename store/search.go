@@ -0,0 +1,297 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+var searchOperators = map[string]bool{"AND": true, "OR": true, "NOT": true}
+
+// searchTokens splits an fts5 query into terms, "quoted phrases", operators and parens
+func searchTokens(query string) []string {
+	var (
+		tokens []string
+		cur    strings.Builder
+		quoted bool
+	)
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			cur.WriteRune(r)
+			if quoted {
+				flush()
+			}
+			quoted = !quoted
+		case quoted:
+			cur.WriteRune(r)
+		case unicode.IsSpace(r):
+			flush()
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+func isBareTerm(term string) bool {
+	term = strings.TrimSuffix(term, "*")
+	if term == "" {
+		return false
+	}
+	for _, r := range term {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func quoteTerm(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+}
+
+// SearchQuery turns command line arguments into an fts5 MATCH expression.
+// Terms are ANDed together, `dep*` is a prefix match, "quoted phrases" and
+// AND/OR/NOT pass through. An argument with spaces and no query syntax,
+// like `mindtick search "race condition"`, is searched as a phrase.
+// Terms with punctuation are quoted so they can't break the query.
+func SearchQuery(args ...string) string {
+	var parts []string
+	for _, arg := range args {
+		tokens := searchTokens(arg)
+		if len(tokens) > 1 && !strings.ContainsAny(arg, `"()`) && !hasOperator(tokens) {
+			parts = append(parts, quoteTerm(arg))
+			continue
+		}
+
+		for _, token := range tokens {
+			switch {
+			case searchOperators[token], token == "(", token == ")":
+				parts = append(parts, token)
+			case strings.HasPrefix(token, `"`):
+				if !strings.HasSuffix(token, `"`) || len(token) == 1 { // unterminated quote
+					token = quoteTerm(strings.Trim(token, `"`))
+				}
+				parts = append(parts, token)
+			case isBareTerm(token):
+				parts = append(parts, token)
+			default:
+				parts = append(parts, quoteTerm(token))
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func hasOperator(tokens []string) bool {
+	for _, token := range tokens {
+		if searchOperators[token] {
+			return true
+		}
+	}
+	return false
+}
+
+// HighlightTerms returns the terms and phrases of an fts5 query that should be
+// highlighted in results, terms that are NOTed out are skipped.
+// Prefix terms keep their trailing `*`.
+func HighlightTerms(query string) []string {
+	var (
+		terms []string
+		skip  bool
+	)
+	for _, token := range searchTokens(query) {
+		switch {
+		case token == "NOT":
+			skip = true
+			continue
+		case searchOperators[token], token == "(", token == ")":
+			continue
+		}
+
+		if !skip {
+			term := strings.ReplaceAll(strings.Trim(token, `"`), `""`, `"`)
+			if term != "" {
+				terms = append(terms, term)
+			}
+		}
+		skip = false
+	}
+	return terms
+}
+
+func invalidSearchErr(query string, err error) error {
+	return fmt.Errorf("invalid search %s: %v", messages.ColorizeStr(query, messages.BrightPurple), err)
+}
+
+// searchExpr is a parsed fts5 query, stores without fts5 match messages with it
+type searchExpr interface {
+	// match reports if the tokens of a message, see textTokens, match the query
+	match(tokens []string) bool
+}
+
+type (
+	phraseExpr struct {
+		terms  []string
+		prefix bool // the last term is a prefix, like dep*
+	}
+	andExpr struct{ l, r searchExpr }
+	orExpr  struct{ l, r searchExpr }
+	notExpr struct{ l, r searchExpr } // l AND NOT r, fts5 has no unary NOT
+)
+
+func (e phraseExpr) match(tokens []string) bool {
+	if len(e.terms) == 0 {
+		return false
+	}
+	for i := 0; i+len(e.terms) <= len(tokens); i++ {
+		matched := true
+		for j, term := range e.terms {
+			token := tokens[i+j]
+			if token != term && !(e.prefix && j == len(e.terms)-1 && strings.HasPrefix(token, term)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (e andExpr) match(tokens []string) bool { return e.l.match(tokens) && e.r.match(tokens) }
+func (e orExpr) match(tokens []string) bool  { return e.l.match(tokens) || e.r.match(tokens) }
+func (e notExpr) match(tokens []string) bool { return e.l.match(tokens) && !e.r.match(tokens) }
+
+// textTokens splits text the way the fts5 unicode61 tokenizer does, lowercased
+// runs of letters and digits
+func textTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchesSearch reports if text matches the parsed query
+func matchesSearch(expr searchExpr, text string) bool {
+	return expr.match(textTokens(text))
+}
+
+// parseSearch parses an fts5 query with the fts5 precedence, NOT binds
+// tighter than AND, which binds tighter than OR. Terms next to each other are ANDed.
+func parseSearch(query string) (searchExpr, error) {
+	p := &searchParser{tokens: searchTokens(query)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("syntax error near %q", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+type searchParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *searchParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *searchParser) or() (searchExpr, error) {
+	l, err := p.and()
+	for err == nil && p.peek() == "OR" {
+		p.pos++
+		var r searchExpr
+		if r, err = p.and(); err == nil {
+			l = orExpr{l, r}
+		}
+	}
+	return l, err
+}
+
+func (p *searchParser) and() (searchExpr, error) {
+	l, err := p.not()
+	for err == nil {
+		switch p.peek() {
+		case "AND":
+			p.pos++
+		case "", "OR", ")":
+			return l, nil
+		}
+		var r searchExpr
+		if r, err = p.not(); err == nil {
+			l = andExpr{l, r}
+		}
+	}
+	return l, err
+}
+
+func (p *searchParser) not() (searchExpr, error) {
+	l, err := p.primary()
+	for err == nil && p.peek() == "NOT" {
+		p.pos++
+		var r searchExpr
+		if r, err = p.primary(); err == nil {
+			l = notExpr{l, r}
+		}
+	}
+	return l, err
+}
+
+func (p *searchParser) primary() (searchExpr, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of query")
+	case token == "(":
+		p.pos++
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return expr, nil
+	case token == ")", searchOperators[token]:
+		return nil, fmt.Errorf("syntax error near %q", token)
+	}
+	p.pos++
+
+	var phrase phraseExpr
+	if text, quoted := strings.CutPrefix(token, `"`); quoted {
+		phrase.terms = textTokens(strings.ReplaceAll(strings.TrimSuffix(text, `"`), `""`, `"`))
+		if p.peek() == "*" {
+			p.pos++
+			phrase.prefix = true
+		}
+	} else {
+		text, phrase.prefix = strings.CutSuffix(token, "*")
+		phrase.terms = textTokens(text)
+	}
+	return phrase, nil
+}
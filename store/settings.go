@@ -0,0 +1,31 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+func (s *SQLite) Setting(key string) (string, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to read setting %s: %v", key, err)
+	}
+	return value, nil
+}
+
+func (s *SQLite) SetSetting(key, value string) error {
+	var err error
+	if value == "" {
+		_, err = s.exec("DELETE FROM settings WHERE key = ?", key)
+	} else {
+		_, err = s.exec("INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value", key, value)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to save setting %s: %v", key, err)
+	}
+	return nil
+}
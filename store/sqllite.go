@@ -2,199 +2,182 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"math/rand/v2"
 	"strings"
 	"time"
 
 	"github.com/ninesl/mindtick/messages"
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
 	//https://pkg.go.dev/modernc.org/sqlite?utm_source=godoc
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
-const DBFileName = "store.mindtick"
-
-var COLORDBFILENAME = messages.ColorizeStr(DBFileName, messages.Purple, messages.BrightCyanBg)
+// SQLite is the default Store, a store.mindtick file
+type SQLite struct {
+	db   *sql.DB
+	path string
+}
 
-func LoadMindtick() (*sql.DB, error) {
-	dir, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("unable to get access to directory: %v", err)
-	}
-
-	for {
-		dbPath := dir + string(os.PathSeparator) + DBFileName
-		// fmt.Println("Checking", dbPath)
-		if _, err := os.Stat(dbPath); err == nil {
-			db, err := sql.Open("sqlite", dbPath)
-			if err != nil {
-				return nil, fmt.Errorf("unable to open %s. Is the file corrupted? %v", COLORDBFILENAME, err)
-			}
-			return db, nil
-		}
+// git hooks, editor plugins and terminals can all write to a store at once
+const (
+	busyTimeout = 5 * time.Second // how long a write waits for the lock held by another mindtick
+	busyRetries = 5               // how often a write sqlite gave up on with SQLITE_BUSY is tried again
+)
 
-		parentDir := dir + string(os.PathSeparator) + ".."
-		parentDir, err = filepath.Abs(parentDir)
-		if err != nil {
-			return nil, fmt.Errorf("unable to resolve parent directory: %v", err)
-		}
-		if parentDir == dir {
-			return nil, fmt.Errorf("%s file not found\n%s to create a new mindtick", COLORDBFILENAME, messages.ColorizeStr("mindtick new", messages.BrightGreen))
-		}
-		dir = parentDir
-	}
-	// return nil, fmt.Errorf("mindtick file not found. you shouldn't have gotten here")
+// dsn opens dbPath so transactions take the write lock when they begin instead of on their first write.
+// WAL mode is stored in the file, OpenSQLite switches to it once instead of every new connection.
+func dsn(dbPath string) string {
+	return fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=synchronous(NORMAL)&_txlock=immediate",
+		dbPath, busyTimeout.Milliseconds())
 }
 
-// `mindtick new` command
-func New() error {
-	// Verify if file exists
-	if _, err := os.Stat(DBFileName); os.IsNotExist(err) {
-		// do nothing
-	} else {
-		return fmt.Errorf("%s already exists", COLORDBFILENAME)
-	}
-
-	//FIXME: only append to git ignore if DBFileName is not already in there
-	// Append to .gitignore if needed
-	content, err := os.ReadFile(".gitignore")
-	if err == nil && !strings.Contains(string(content), DBFileName) {
-		err = os.WriteFile(".gitignore", []byte(string(content)+"\n"+DBFileName+"\n"), 0644)
-		if err != nil {
-			return fmt.Errorf("failed to update .gitignore: %v", err)
-		}
+func isBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
 	}
+	code := sqliteErr.Code() & 0xff // without the extended result code
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
 
-	file, err := os.Create(DBFileName)
-	if err != nil {
-		return fmt.Errorf("failed to create %s %v", COLORDBFILENAME, err)
+// retry runs write again, after a random backoff, while it fails with SQLITE_BUSY.
+// busy_timeout already waits for the lock, sqlite only gives up right away when
+// waiting could deadlock or another mindtick held the lock for longer than busyTimeout.
+func retry(write func() error) error {
+	err := write()
+	for attempt := 0; attempt < busyRetries && isBusy(err); attempt++ {
+		backoff := time.Duration(10<<attempt) * time.Millisecond
+		time.Sleep(backoff/2 + rand.N(backoff))
+		err = write()
 	}
-	defer file.Close()
+	return err
+}
 
-	// setup database schema
-	db, err := LoadMindtick()
-	if err != nil {
+// exec runs a write outside of a transaction
+func (s *SQLite) exec(query string, args ...any) (res sql.Result, err error) {
+	err = retry(func() error {
+		res, err = s.db.Exec(query, args...)
 		return err
-	}
-	createSchema(db)
+	})
+	return res, err
+}
 
-	return fmt.Errorf("%s %s", COLORDBFILENAME, messages.ColorizeStr("intialized", messages.BrightPurple))
+// begin starts a transaction, the writes in it never wait for the lock
+func begin(db *sql.DB) (tx *sql.Tx, err error) {
+	err = retry(func() error {
+		tx, err = db.Begin()
+		return err
+	})
+	return tx, err
 }
 
-func Delete() error {
-	_, err := LoadMindtick()
+// OpenSQLite opens the store at dbPath, upgrades its schema to SchemaVersion
+// and makes its tags the ones messages renders
+func OpenSQLite(dbPath string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", dsn(dbPath))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("unable to open %s. Is the file corrupted? %v", COLORDBFILENAME, err)
 	}
-	err = os.Remove(DBFileName)
+	// WAL mode so readers never block the writer, switching takes an exclusive
+	// lock that another mindtick opening the store at the same time may hold
+	err = retry(func() error {
+		_, err := db.Exec("PRAGMA journal_mode = WAL")
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to remove %s\n%v", COLORDBFILENAME, err)
+		db.Close()
+		return nil, fmt.Errorf("unable to open %s. Is the file corrupted? %v", COLORDBFILENAME, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &SQLite{db: db, path: dbPath}
+	if err := retry(func() error { return s.loadTags(db) }); err != nil {
+		db.Close()
+		return nil, err
 	}
-	return fmt.Errorf("%s %s", COLORDBFILENAME, messages.ColorizeStr("deleted", messages.BrightPurple))
+	return s, nil
 }
 
-func createSchema(db *sql.DB) error {
-	// schema is
-	// messages
-	// 	id - serial
-	// 	timestamp - time
-	// 	msg - string
-	// 	msgtype - int enums in messages.go
+func (s *SQLite) Path() string {
+	return s.path
+}
 
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS messages (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		timestamp DATETIME,
-		msg TEXT,
-		msgtype INT
-	)`)
-	if err != nil {
-		return fmt.Errorf("unable to create mindtick schema: %v", err)
-	}
-	return nil
+func (s *SQLite) Close() error {
+	return s.db.Close()
 }
 
-func AddMessage(db *sql.DB, message messages.Message) error {
-	_, err := db.Exec("INSERT INTO messages (timestamp, msg, msgtype) VALUES (?, ?, ?)", message.Timestamp, message.Msg, message.Tag)
+func (s *SQLite) AddMessage(message messages.Message) (messages.Message, error) {
+	message.Timestamp = localTime(message.Timestamp)
+	res, err := s.exec("INSERT INTO messages (timestamp, msg, msgtype, due, priority, git_repo, git_branch, git_commit, git_dirty, private) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		message.Timestamp, message.Msg, message.Tag, nullTime(message.Due), message.Priority,
+		nullString(message.Git.Repo), nullString(message.Git.Branch), nullString(message.Git.Commit), message.Git.Dirty, message.Private)
 	if err != nil {
-		return fmt.Errorf("unable to add message: %v", err)
+		return message, fmt.Errorf("unable to add message: %v", err)
 	}
-	return nil
+	id, err := res.LastInsertId()
+	if err != nil {
+		return message, fmt.Errorf("unable to add message: %v", err)
+	}
+	message.ID = int(id)
+	return message, nil
 }
 
-type Range uint8
-
-const (
-	ANYTIME Range = iota
-	TODAY
-	YESTERDAY
-	WEEK
-	MONTH
-)
-
-var (
-	StrToRange = map[string]Range{
-		"today":     TODAY,
-		"yesterday": YESTERDAY,
-		"week":      WEEK,
-		"month":     MONTH,
-	}
-	RangeToStr = map[Range]string{ // dumb
-		TODAY:     "today",
-		YESTERDAY: "yesterday",
-		WEEK:      "week",
-		MONTH:     "month",
-	}
-	RangeToTime = map[Range]func() time.Time{
-		TODAY: func() time.Time {
-			now := time.Now()
-			return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		},
-		YESTERDAY: func() time.Time {
-			now := time.Now()
-			yesterday := now.AddDate(0, 0, -1)
-			return time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 0, 0, 0, 0, now.Location())
-		},
-		WEEK: func() time.Time {
-			now := time.Now()
-			weekAgo := now.AddDate(0, 0, -7)
-			return time.Date(weekAgo.Year(), weekAgo.Month(), weekAgo.Day(), 0, 0, 0, 0, now.Location())
-		},
-		MONTH: func() time.Time {
-			now := time.Now()
-			monthAgo := now.AddDate(0, -1, 0)
-			return time.Date(monthAgo.Year(), monthAgo.Month(), monthAgo.Day(), 0, 0, 0, 0, now.Location())
-		},
-	}
-	RangeOrder = []Range{TODAY, YESTERDAY, WEEK, MONTH}
-)
-
-func Messages(db *sql.DB, tag messages.Tag, rangeType Range) ([]messages.Message, error) {
-	var SQLstmt string
-	var rows *sql.Rows
-	var err error
+// whereClause is the WHERE clause matching f, empty for the zero Filter
+func whereClause(f Filter) (string, []any) {
+	var (
+		where []string
+		args  []any
+	)
 
-	if rangeType == ANYTIME && tag == messages.ANYTAG {
-		SQLstmt = "SELECT * FROM messages ORDER BY timestamp"
-		rows, err = db.Query(SQLstmt)
+	if f.Tag != messages.ANYTAG {
+		where = append(where, "msgtype = ?")
+		args = append(args, f.Tag)
 	}
-
-	if rangeType != ANYTIME && tag == messages.ANYTAG {
-		SQLstmt = "SELECT * FROM messages WHERE timestamp > ? ORDER BY timestamp"
-		rows, err = db.Query(SQLstmt, RangeToTime[rangeType]())
+	if !f.Range.Start.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, localTime(f.Range.Start))
 	}
-
-	if rangeType == ANYTIME && tag != messages.ANYTAG {
-		SQLstmt = "SELECT * FROM messages WHERE msgtype = ? ORDER BY timestamp"
-		rows, err = db.Query(SQLstmt, tag)
+	if !f.Range.End.IsZero() {
+		where = append(where, "timestamp < ?")
+		args = append(args, localTime(f.Range.End))
 	}
-
-	if rangeType != ANYTIME && tag != messages.ANYTAG {
-		SQLstmt = "SELECT * FROM messages WHERE msgtype = ? AND timestamp >= ? ORDER BY timestamp"
-		rows, err = db.Query(SQLstmt, tag, RangeToTime[rangeType]())
+	if len(f.Statuses) > 0 {
+		where = append(where, "status IN (?"+strings.Repeat(", ?", len(f.Statuses)-1)+")")
+		for _, status := range f.Statuses {
+			args = append(args, status)
+		}
+	}
+	if f.Branch != "" {
+		where = append(where, "git_branch = ?")
+		args = append(args, f.Branch)
 	}
+	if f.IDs != (IDRange{}) {
+		where = append(where, "id BETWEEN ? AND ?")
+		args = append(args, f.IDs.From, f.IDs.To)
+	}
+	if f.Query != "" {
+		where = append(where, "id IN (SELECT rowid FROM messages_fts WHERE messages_fts MATCH ?)")
+		args = append(args, f.Query)
+	}
+	if len(where) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+func (s *SQLite) Messages(f Filter) ([]messages.Message, error) {
+	where, args := whereClause(f)
+	SQLstmt := "SELECT " + messageColumns + " FROM messages" + where + " ORDER BY timestamp, id"
 
+	rows, err := s.db.Query(SQLstmt, args...)
 	if err != nil {
+		if f.Query != "" {
+			return nil, invalidSearchErr(f.Query, err)
+		}
 		return nil, fmt.Errorf("unable to query messages: %v", err)
 	}
 	defer rows.Close()
@@ -202,24 +185,151 @@ func Messages(db *sql.DB, tag messages.Tag, rangeType Range) ([]messages.Message
 	return processRows(rows)
 }
 
+// messageColumns matches the Scan order in processRows
+const messageColumns = "id, timestamp, msg, msgtype, status, completed_at, due, priority, git_repo, git_branch, git_commit, git_dirty, private"
+
+// localTime is how times are written: sqlite compares them as text and Stats
+// reads the date and hour from the text, so every time is on the local wall clock
+func localTime(t time.Time) time.Time {
+	return t.In(time.Local)
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return localTime(t)
+}
+
+// nullString stores "" as NULL
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 func processRows(rows *sql.Rows) ([]messages.Message, error) {
 	var msgs []messages.Message
 	for rows.Next() {
-		var msg messages.Message
-		err := rows.Scan(&msg.ID, &msg.Timestamp, &msg.Msg, &msg.Tag)
+		var (
+			msg         messages.Message
+			completedAt sql.NullTime
+			due         sql.NullTime
+			repo        sql.NullString
+			branch      sql.NullString
+			commit      sql.NullString
+		)
+		err := rows.Scan(&msg.ID, &msg.Timestamp, &msg.Msg, &msg.Tag, &msg.Status, &completedAt, &due, &msg.Priority,
+			&repo, &branch, &commit, &msg.Git.Dirty, &msg.Private)
 		if err != nil {
 			return nil, fmt.Errorf("unable to scan messages: %v", err)
 		}
+		msg.CompletedAt = completedAt.Time
+		msg.Due = due.Time
+		msg.Git = messages.Git{Repo: repo.String, Branch: branch.String, Commit: commit.String, Dirty: msg.Git.Dirty}
 		msgs = append(msgs, msg)
 	}
 	return msgs, nil
 }
 
-// `mindtick edit` command?
-func ChangeTimestamp(db *sql.DB, id int, timestamp time.Time) error {
-	_, err := db.Exec("UPDATE messages SET timestamp = ? WHERE id = ?", timestamp, id)
+func noMessageErr(id int) error {
+	return fmt.Errorf("no message with id %s", messages.ColorizeStr(fmt.Sprint(id), messages.BrightPurple))
+}
+
+// Message returns the message stored under id
+func (s *SQLite) Message(id int) (messages.Message, error) {
+	rows, err := s.db.Query("SELECT "+messageColumns+" FROM messages WHERE id = ?", id)
+	if err != nil {
+		return messages.Message{}, fmt.Errorf("unable to query message: %v", err)
+	}
+	defer rows.Close()
+
+	msgs, err := processRows(rows)
+	if err != nil {
+		return messages.Message{}, err
+	}
+	if len(msgs) == 0 {
+		return messages.Message{}, noMessageErr(id)
+	}
+	return msgs[0], nil
+}
+
+// update runs an UPDATE or DELETE of the message with id, what names the change in errors
+func (s *SQLite) update(id int, what string, query string, args ...any) error {
+	res, err := s.exec(query, append(args, id)...)
+	if err != nil {
+		return fmt.Errorf("unable to %s: %v", what, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return noMessageErr(id)
+	}
+	return nil
+}
+
+// updateAll runs an UPDATE or DELETE of each message in ids in one transaction
+func (s *SQLite) updateAll(ids []int, what string, query string, args ...any) error {
+	tx, err := begin(s.db)
 	if err != nil {
-		return fmt.Errorf("unable to update timestamp: %v", err)
+		return fmt.Errorf("unable to %s: %v", what, err)
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		res, err := tx.Exec(query, append(args, id)...)
+		if err != nil {
+			return fmt.Errorf("unable to %s: %v", what, err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return noMessageErr(id)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to %s: %v", what, err)
 	}
 	return nil
 }
+
+// `mindtick edit` command
+func (s *SQLite) EditMessage(id int, msg string) error {
+	return s.update(id, "update message", "UPDATE messages SET msg = ? WHERE id = ?", msg)
+}
+
+// `mindtick retag` command
+func (s *SQLite) ChangeTag(id int, tag messages.Tag) error {
+	return s.update(id, "update tag", "UPDATE messages SET msgtype = ? WHERE id = ?", tag)
+}
+
+// `mindtick rm` command
+func (s *SQLite) DeleteMessages(ids []int) error {
+	return s.updateAll(ids, "delete messages", "DELETE FROM messages WHERE id = ?")
+}
+
+// `mindtick done`, `cancel` and `reopen` commands, at is when a task was closed
+func (s *SQLite) SetStatus(ids []int, status messages.Status, at time.Time) error {
+	var completedAt time.Time
+	if status != messages.OPEN {
+		completedAt = at
+	}
+	return s.updateAll(ids, "update status", "UPDATE messages SET status = ?, completed_at = ? WHERE id = ?", status, nullTime(completedAt))
+}
+
+// `mindtick private` and `public` commands
+func (s *SQLite) SetPrivate(ids []int, private bool) error {
+	return s.updateAll(ids, "update privacy", "UPDATE messages SET private = ? WHERE id = ?", private)
+}
+
+func (s *SQLite) Overdue(now time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM messages WHERE msgtype = ? AND status = ? AND due IS NOT NULL AND due <= ?",
+		messages.TASK, messages.OPEN, localTime(now)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("unable to count overdue tasks: %v", err)
+	}
+	return count, nil
+}
+
+// `mindtick retime` command
+func (s *SQLite) ChangeTimestamp(id int, timestamp time.Time) error {
+	return s.update(id, "update timestamp", "UPDATE messages SET timestamp = ? WHERE id = ?", localTime(timestamp))
+}
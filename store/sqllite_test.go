@@ -0,0 +1,121 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+const (
+	stressWriters = 8  // goroutines and processes each
+	stressWrites  = 40 // messages each writer adds
+)
+
+// stressWriterEnv makes the test binary a writer process adding to the store in it
+const stressWriterEnv = "MINDTICK_STRESS_WRITER"
+
+// stressWrite adds the messages of writer, every fifth one with an import to also take a transaction
+func stressWrite(s *SQLite, writer string) error {
+	for i := range stressWrites {
+		text := fmt.Sprintf("%s %d", writer, i)
+		var err error
+		if i%5 == 0 {
+			_, err = s.Import([]Imported{{Timestamp: time.Now(), Tag: "note", Text: text}}, false, false)
+		} else {
+			_, err = s.AddMessage(messages.Message{Timestamp: time.Now(), Tag: messages.WIN, Msg: text})
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", text, err)
+		}
+	}
+	return nil
+}
+
+// TestConcurrentWrites opens a new store from several processes at once,
+// like hooks, editors and terminals do, while goroutines share a connection pool
+func TestConcurrentWrites(t *testing.T) {
+	if path := os.Getenv(stressWriterEnv); path != "" {
+		s, err := OpenSQLite(path)
+		if err == nil {
+			err = stressWrite(s, "process "+os.Getenv(stressWriterEnv+"_ID"))
+			s.Close()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+
+	path := filepath.Join(t.TempDir(), DBFileName)
+	var procs []*exec.Cmd
+	stdouts, stderrs := make([]bytes.Buffer, stressWriters), make([]bytes.Buffer, stressWriters)
+	for i := range stressWriters {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestConcurrentWrites$")
+		cmd.Env = append(os.Environ(), stressWriterEnv+"="+path, stressWriterEnv+"_ID="+strconv.Itoa(i))
+		cmd.Stdout, cmd.Stderr = &stdouts[i], &stderrs[i]
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		procs = append(procs, cmd)
+	}
+
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var wg sync.WaitGroup
+	errs := make(chan error, stressWriters)
+	for i := range stressWriters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := stressWrite(s, "goroutine "+strconv.Itoa(i)); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	for i, cmd := range procs {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("process %d: %v\nstderr:\n%s\nstdout:\n%s", i, err, stderrs[i].String(), stdouts[i].String())
+		}
+	}
+
+	msgs, err := s.Messages(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, msg := range msgs {
+		if seen[msg.Msg] {
+			t.Errorf("%q was added twice", msg.Msg)
+		}
+		seen[msg.Msg] = true
+	}
+	for _, writer := range []string{"process", "goroutine"} {
+		for i := range stressWriters {
+			for j := range stressWrites {
+				if text := fmt.Sprintf("%s %d %d", writer, i, j); !seen[text] {
+					t.Errorf("%q was lost", text)
+				}
+			}
+		}
+	}
+}
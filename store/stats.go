@@ -0,0 +1,188 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Stats are the message counts of `mindtick stats`, aggregated by the store.
+// Days and hours are the wall clock in time.Local, the zone stores keep timestamps in.
+type Stats struct {
+	Tags     []TagCount // ordered by tag id, tags without messages are left out
+	Weekdays [7]int     // indexed by time.Weekday
+	Hours    [24]int
+	Days     []DayCount // days with messages, oldest first
+}
+
+// TagCount is how many messages use Tag
+type TagCount struct {
+	Tag messages.Tag
+	N   int
+}
+
+// DayCount is how many messages were added on Day, midnight in time.Local
+type DayCount struct {
+	Day time.Time
+	N   int
+}
+
+// Total is the number of messages counted
+func (s Stats) Total() int {
+	var n int
+	for _, tc := range s.Tags {
+		n += tc.N
+	}
+	return n
+}
+
+// Busiest are the n days with the most messages, earlier days first on a tie
+func (s Stats) Busiest(n int) []DayCount {
+	days := slices.Clone(s.Days)
+	slices.SortStableFunc(days, func(a, b DayCount) int { return cmp.Compare(b.N, a.N) })
+	return days[:min(n, len(days))]
+}
+
+// Streaks are the days in a row with messages: the current streak runs until
+// today, or yesterday while nothing was added today yet, and the longest one
+func (s Stats) Streaks(now time.Time) (current, longest int) {
+	var (
+		run  int
+		prev time.Time
+	)
+	for _, dc := range s.Days {
+		if !prev.IsZero() && dc.Day.Equal(prev.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+		prev = dc.Day
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if prev.Equal(today) || prev.Equal(today.AddDate(0, 0, -1)) {
+		current = run
+	}
+	return current, longest
+}
+
+// PerDay is the average number of messages per day of r, an unbounded side
+// of r is the first day with messages or today
+func (s Stats) PerDay(r Range, now time.Time) float64 {
+	if len(s.Days) == 0 {
+		return 0
+	}
+	start, end := r.Start, r.End
+	if start.IsZero() {
+		start = s.Days[0].Day
+	}
+	if end.IsZero() || end.After(now) {
+		end = now
+	}
+	days := 0
+	for d := startOfDay(start); d.Before(end); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	return float64(s.Total()) / float64(max(days, 1))
+}
+
+// dayOf parses the date of a day count
+func dayOf(date string) (time.Time, error) {
+	return time.ParseInLocation(time.DateOnly, date, time.Local)
+}
+
+// Stats groups the messages matching f in sqlite, timestamps are written through localTime
+// as "2006-01-02 15:04:05.999999999 -0700 MST" so the date and hour are substrings
+func (s *SQLite) Stats(f Filter) (Stats, error) {
+	var stats Stats
+	where, args := whereClause(f)
+	group := func(key string, scan func(key string, n int) error) error {
+		rows, err := s.db.Query("SELECT "+key+", COUNT(*) FROM messages"+where+" GROUP BY 1 ORDER BY 1", args...)
+		if err != nil {
+			if f.Query != "" {
+				return invalidSearchErr(f.Query, err)
+			}
+			return fmt.Errorf("unable to count messages: %v", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				k string
+				n int
+			)
+			if err := rows.Scan(&k, &n); err != nil {
+				return fmt.Errorf("unable to count messages: %v", err)
+			}
+			if err := scan(k, n); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+
+	err := group("msgtype", func(key string, n int) error {
+		var tag messages.Tag
+		_, err := fmt.Sscan(key, &tag)
+		stats.Tags = append(stats.Tags, TagCount{Tag: tag, N: n})
+		return err
+	})
+	if err == nil {
+		err = group("CAST(strftime('%w', substr(timestamp, 1, 10)) AS INT)", func(key string, n int) error {
+			var wd int
+			_, err := fmt.Sscan(key, &wd)
+			stats.Weekdays[wd%7] = n
+			return err
+		})
+	}
+	if err == nil {
+		err = group("CAST(substr(timestamp, 12, 2) AS INT)", func(key string, n int) error {
+			var hour int
+			_, err := fmt.Sscan(key, &hour)
+			stats.Hours[hour%24] = n
+			return err
+		})
+	}
+	if err == nil {
+		err = group("substr(timestamp, 1, 10)", func(key string, n int) error {
+			day, err := dayOf(key)
+			stats.Days = append(stats.Days, DayCount{Day: day, N: n})
+			return err
+		})
+	}
+	return stats, err
+}
+
+// Stats counts the messages matching f
+func (m *Memory) Stats(f Filter) (Stats, error) {
+	var stats Stats
+	msgs, err := m.Messages(f)
+	if err != nil {
+		return stats, err
+	}
+	tags := map[messages.Tag]int{}
+	for _, msg := range msgs {
+		local := msg.Timestamp.In(time.Local) // a log replayed from another zone keeps its offsets
+		tags[msg.Tag]++
+		stats.Weekdays[local.Weekday()]++
+		stats.Hours[local.Hour()]++
+
+		day, err := dayOf(local.Format(time.DateOnly))
+		if err != nil {
+			return stats, err
+		}
+		i, found := slices.BinarySearchFunc(stats.Days, day, func(dc DayCount, day time.Time) int { return dc.Day.Compare(day) })
+		if !found {
+			stats.Days = slices.Insert(stats.Days, i, DayCount{Day: day})
+		}
+		stats.Days[i].N++
+	}
+	for tag, n := range tags {
+		stats.Tags = append(stats.Tags, TagCount{Tag: tag, N: n})
+	}
+	slices.SortFunc(stats.Tags, func(a, b TagCount) int { return cmp.Compare(a.Tag, b.Tag) })
+	return stats, nil
+}
@@ -0,0 +1,236 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// Store keeps the messages, tags and settings of a mindtick. Opening a store
+// and changing its tags makes them the tags messages resolves and renders.
+type Store interface {
+	// AddMessage stores msg under a new id, ids are never reused
+	AddMessage(msg messages.Message) (messages.Message, error)
+	// Messages returns the messages matching f ordered by timestamp
+	Messages(f Filter) ([]messages.Message, error)
+	Message(id int) (messages.Message, error)
+	EditMessage(id int, text string) error
+	ChangeTag(id int, tag messages.Tag) error
+	ChangeTimestamp(id int, timestamp time.Time) error
+	// SetStatus sets the status of tasks in one go like SetPrivate, at is when they were closed
+	SetStatus(ids []int, status messages.Status, at time.Time) error
+	// SetPrivate marks messages private, private messages are left out of client reports.
+	// The ids are changed in one go, nothing changes when one of them doesn't exist.
+	SetPrivate(ids []int, private bool) error
+	// DeleteMessages deletes the messages with ids in one go, like SetPrivate
+	DeleteMessages(ids []int) error
+	// Stats counts the messages matching f per tag, weekday, hour and day
+	Stats(f Filter) (Stats, error)
+	// Overdue counts the open tasks that were due at or before now
+	Overdue(now time.Time) (int, error)
+
+	// Tags returns the tags in the order they were created
+	Tags() ([]messages.TagDef, error)
+	// AddTag stores def under a new id, def.ID is ignored and set on the returned tag
+	AddTag(def messages.TagDef) (messages.TagDef, error)
+	RenameTag(tag messages.Tag, name string) error
+	// TagUsage counts the messages using tag
+	TagUsage(tag messages.Tag) (int, error)
+	// RemoveTag moves the messages using tag to reassign, or deletes
+	// them along with the tag when reassign is messages.ANYTAG
+	RemoveTag(tag messages.Tag, reassign messages.Tag) error
+
+	// Setting returns the value stored under key, "" if it was never set
+	Setting(key string) (string, error)
+	// SetSetting stores value under key, an empty value removes the setting
+	SetSetting(key, value string) error
+
+	// Import adds entries, see ImportResult
+	Import(entries []Imported, createTags, dryRun bool) (ImportResult, error)
+	// Path is the file the store was opened from, empty for a Memory
+	Path() string
+	Close() error
+}
+
+// Filter narrows down Messages, the zero value matches every message
+type Filter struct {
+	Tag      messages.Tag
+	Range    Range             // see ParseRange
+	Query    string            // fts5 MATCH expression, build it with SearchQuery
+	Statuses []messages.Status // any of these, nil matches every status
+	Branch   string            // the git branch messages were added on
+	IDs      IDRange           // the zero IDRange matches every id
+}
+
+// IDRange are the message ids From to To, both included
+type IDRange struct {
+	From, To int
+}
+
+const (
+	DBFileName    = "store.mindtick"
+	JSONLFileName = "mindtick.jsonl" // an append only log, meant to be committed
+)
+
+var COLORDBFILENAME = messages.ColorizeStr(DBFileName, messages.Purple, messages.BrightCyanBg)
+
+// Open opens the store at path, a JSONL log when it ends in .jsonl and SQLite otherwise
+func Open(path string) (Store, error) {
+	if strings.HasSuffix(path, ".jsonl") {
+		return OpenJSONL(path)
+	}
+	return OpenSQLite(path)
+}
+
+// LoadMindtick opens the store FindMindtick finds
+func LoadMindtick() (Store, error) {
+	return loadMindtick(false)
+}
+
+// LoadOrCreateMindtick is LoadMindtick for commands adding messages,
+// which create the global store when it is used for the first time
+func LoadOrCreateMindtick() (Store, error) {
+	return loadMindtick(true)
+}
+
+func loadMindtick(createGlobal bool) (Store, error) {
+	dbPath, err := findMindtick(createGlobal)
+	if err != nil {
+		return nil, err
+	}
+	return Open(dbPath)
+}
+
+// storeIn returns the store in dir, store.mindtick before mindtick.jsonl
+func storeIn(dir string) (string, bool) {
+	for _, name := range []string{DBFileName, JSONLFileName} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// FindMindtick returns the path of the store to use: the global one with Global set,
+// otherwise the nearest store.mindtick or mindtick.jsonl walking up from Dir.
+// Finding a store never creates one.
+func FindMindtick() (string, error) {
+	return findMindtick(false)
+}
+
+func findMindtick(createGlobal bool) (string, error) {
+	if Global {
+		return globalStore(createGlobal)
+	}
+
+	dir, err := workDir()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if dbPath, ok := storeIn(dir); ok {
+			return dbPath, nil
+		}
+
+		parentDir := dir + string(os.PathSeparator) + ".."
+		parentDir, err = filepath.Abs(parentDir)
+		if err != nil {
+			return "", fmt.Errorf("unable to resolve parent directory: %v", err)
+		}
+		if parentDir == dir {
+			if os.Getenv(FallbackEnv) == "global" {
+				return globalStore(createGlobal)
+			}
+			return "", fmt.Errorf("%s file not found\n%s to create a new mindtick or %s to use your global one", COLORDBFILENAME,
+				messages.ColorizeStr("mindtick new", messages.BrightGreen), messages.ColorizeStr("mindtick -g", messages.BrightGreen))
+		}
+		dir = parentDir
+	}
+}
+
+// `mindtick new` command, name is DBFileName or JSONLFileName
+func New(name string) error {
+	if Global {
+		return newGlobal(name)
+	}
+
+	dir, err := workDir()
+	if err != nil {
+		return err
+	}
+	if existing, ok := storeIn(dir); ok {
+		return fmt.Errorf("%s already exists", messages.ColorizeStr(filepath.Base(existing), messages.Purple, messages.BrightCyanBg))
+	}
+	dbPath := filepath.Join(dir, name)
+
+	// the JSONL log is meant to be committed, only the SQLite store and its -wal and -shm files are ignored
+	gitignore := filepath.Join(dir, ".gitignore")
+	content, err := os.ReadFile(gitignore)
+	if err == nil && name == DBFileName && !strings.Contains(string(content), DBFileName) {
+		err = os.WriteFile(gitignore, []byte(string(content)+"\n"+DBFileName+"*\n"), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to update .gitignore: %v", err)
+		}
+	}
+
+	// opening creates the file and brings it to the current schema
+	db, err := Open(dbPath)
+	if err != nil {
+		return err
+	}
+	db.Close()
+
+	return fmt.Errorf("%s %s", messages.ColorizeStr(name, messages.Purple, messages.BrightCyanBg), messages.ColorizeStr("intialized", messages.BrightPurple))
+}
+
+func newGlobal(name string) error {
+	dir, err := globalDir()
+	if err != nil {
+		return err
+	}
+	if existing, ok := storeIn(dir); ok {
+		return fmt.Errorf("global %s already exists at %s", COLORDBFILENAME, existing)
+	}
+
+	dbPath := filepath.Join(dir, name)
+	db, err := Open(dbPath)
+	if err != nil {
+		return err
+	}
+	db.Close()
+	return fmt.Errorf("global %s %s at %s", COLORDBFILENAME, messages.ColorizeStr("intialized", messages.BrightPurple), dbPath)
+}
+
+func Delete() error {
+	dbPath, err := FindMindtick()
+	if err != nil {
+		return err
+	}
+	if !Global { // only the store of this directory, not one found further up
+		dir, err := workDir()
+		if err != nil {
+			return err
+		}
+		if filepath.Dir(dbPath) != dir {
+			return fmt.Errorf("no %s in this directory, the nearest one is %s", COLORDBFILENAME, dbPath)
+		}
+	}
+
+	err = os.Remove(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s\n%v", COLORDBFILENAME, err)
+	}
+	// left behind by a mindtick that didn't exit cleanly
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s\n%v", dbPath+suffix, err)
+		}
+	}
+	return fmt.Errorf("%s %s", messages.ColorizeStr(filepath.Base(dbPath), messages.Purple, messages.BrightCyanBg), messages.ColorizeStr("deleted", messages.BrightPurple))
+}
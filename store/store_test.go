@@ -0,0 +1,622 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// backends open an empty store, reopen closes it and opens it again
+var backends = []struct {
+	name string
+	open func(t *testing.T) (s Store, reopen func() Store)
+}{
+	{"sqlite", func(t *testing.T) (Store, func() Store) {
+		path := filepath.Join(t.TempDir(), DBFileName)
+		return openOrFail(t, path), func() Store { return openOrFail(t, path) }
+	}},
+	{"memory", func(t *testing.T) (Store, func() Store) {
+		s := NewMemory()
+		return s, func() Store { return s }
+	}},
+	{"jsonl", func(t *testing.T) (Store, func() Store) {
+		path := filepath.Join(t.TempDir(), JSONLFileName)
+		return openOrFail(t, path), func() Store { return openOrFail(t, path) }
+	}},
+}
+
+func openOrFail(t *testing.T, path string) Store {
+	t.Helper()
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+var start = time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)
+
+var fixGit = messages.Git{Repo: "mindtick", Branch: "feature/x", Commit: "3f2a9c1d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a39", Dirty: true}
+
+// seed adds a win, a fix and two tasks an hour apart, the second task is due at noon
+func seed(t *testing.T, s Store) []messages.Message {
+	t.Helper()
+	var added []messages.Message
+	for i, msg := range []messages.Message{
+		{Tag: messages.WIN, Msg: "shipped the importer"},
+		{Tag: messages.FIX, Msg: "race condition in the cache", Git: fixGit},
+		{Tag: messages.TASK, Msg: "deploy to staging", Priority: messages.HIGH},
+		{Tag: messages.TASK, Msg: "deployment docs", Due: start.Add(3 * time.Hour)},
+	} {
+		msg.Timestamp = start.Add(time.Duration(i) * time.Hour)
+		msg, err := s.AddMessage(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		added = append(added, msg)
+	}
+	return added
+}
+
+// localZone sets time.Local to loc for the rest of the test
+func localZone(t *testing.T, loc *time.Location) {
+	local := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = local })
+}
+
+func texts(t *testing.T, s Store, f Filter) []string {
+	t.Helper()
+	msgs, err := s.Messages(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, msg := range msgs {
+		texts = append(texts, msg.Msg)
+	}
+	return texts
+}
+
+func mustDo(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+var conformance = []struct {
+	name string
+	test func(t *testing.T, s Store, reopen func() Store)
+}{
+	{"add and read back", func(t *testing.T, s Store, reopen func() Store) {
+		added := seed(t, s)
+		for i, msg := range added {
+			if msg.ID != i+1 {
+				t.Errorf("message %d got id %d", i+1, msg.ID)
+			}
+		}
+		s.Close()
+		s = reopen()
+		defer s.Close()
+
+		got, err := s.Message(4)
+		mustDo(t, err)
+		want := added[3]
+		if got.Msg != want.Msg || got.Tag != want.Tag || !got.Timestamp.Equal(want.Timestamp) || !got.Due.Equal(want.Due) || got.Status != messages.OPEN {
+			t.Errorf("Message(4) = %+v, want %+v", got, want)
+		}
+		if got, _ := s.Message(2); got.Git != fixGit {
+			t.Errorf("git of message 2 = %+v, want %+v", got.Git, fixGit)
+		}
+		if got, _ := s.Message(3); got.Priority != messages.HIGH {
+			t.Errorf("priority of message 3 = %v, want high", got.Priority)
+		}
+		if _, err := s.Message(5); err == nil {
+			t.Error("Message(5) should fail")
+		}
+	}},
+	{"filters", func(t *testing.T, s Store, _ func() Store) {
+		seed(t, s)
+		tests := []struct {
+			f    Filter
+			want []string
+		}{
+			{Filter{}, []string{"shipped the importer", "race condition in the cache", "deploy to staging", "deployment docs"}},
+			{Filter{Tag: messages.TASK}, []string{"deploy to staging", "deployment docs"}},
+			{Filter{Range: Range{Start: start.Add(time.Hour), End: start.Add(3 * time.Hour)}}, []string{"race condition in the cache", "deploy to staging"}},
+			{Filter{Query: "deploy*"}, []string{"deploy to staging", "deployment docs"}},
+			{Filter{Query: "deploy"}, []string{"deploy to staging"}},
+			{Filter{Query: `"the cache"`}, []string{"race condition in the cache"}},
+			{Filter{Query: `"cache the"`}, nil},
+			{Filter{Query: "deploy* NOT docs"}, []string{"deploy to staging"}},
+			{Filter{Query: "importer OR cache"}, []string{"shipped the importer", "race condition in the cache"}},
+			{Filter{Query: "the AND (importer OR staging)"}, []string{"shipped the importer"}},
+			{Filter{Query: SearchQuery("RACE", "condition")}, []string{"race condition in the cache"}},
+			{Filter{Tag: messages.TASK, Query: "docs"}, []string{"deployment docs"}},
+			{Filter{Branch: "feature/x"}, []string{"race condition in the cache"}},
+			{Filter{Branch: "feature"}, nil},
+		}
+		for _, tt := range tests {
+			if got := texts(t, s, tt.f); !slices.Equal(got, tt.want) {
+				t.Errorf("Messages(%+v) = %q, want %q", tt.f, got, tt.want)
+			}
+		}
+		if _, err := s.Messages(Filter{Query: "AND"}); err == nil {
+			t.Error("an invalid query should fail")
+		}
+	}},
+	{"ordered by timestamp", func(t *testing.T, s Store, _ func() Store) {
+		seed(t, s)
+		mustDo(t, s.ChangeTimestamp(1, start.Add(10*time.Hour)))
+		got := texts(t, s, Filter{})
+		if got[len(got)-1] != "shipped the importer" {
+			t.Errorf("retimed message should be last, got %q", got)
+		}
+	}},
+	{"stats zones", func(t *testing.T, s Store, _ func() Store) {
+		localZone(t, time.FixedZone("EST", -5*60*60))
+		_, err := s.Import([]Imported{
+			{Timestamp: time.Date(2026, 3, 12, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60)), Tag: "win", Text: "tokyo"},
+			{Timestamp: time.Date(2026, 3, 12, 3, 0, 0, 0, time.UTC), Tag: "win", Text: "utc"},
+			{Timestamp: time.Date(2026, 3, 11, 20, 0, 0, 0, time.Local), Tag: "note", Text: "local"},
+			{Timestamp: time.Date(2026, 3, 12, 14, 0, 0, 0, time.UTC), Tag: "fix", Text: "utc morning"},
+		}, false, false)
+		mustDo(t, err)
+		// adds and retimes in other zones are stored on the local wall clock too
+		_, err = s.AddMessage(messages.Message{Timestamp: time.Date(2026, 3, 12, 10, 30, 0, 0, time.FixedZone("JST", 9*60*60)), Tag: messages.NOTE, Msg: "added in tokyo"})
+		mustDo(t, err)
+		mustDo(t, s.ChangeTimestamp(4, time.Date(2026, 3, 12, 15, 0, 0, 0, time.UTC)))
+
+		// counted on the local wall clock, a wednesday evening and a thursday morning in EST
+		stats, err := s.Stats(Filter{})
+		mustDo(t, err)
+		if stats.Weekdays != [7]int{time.Wednesday: 4, time.Thursday: 1} {
+			t.Errorf("weekdays = %v", stats.Weekdays)
+		}
+		if stats.Hours != [24]int{10: 1, 19: 1, 20: 2, 22: 1} {
+			t.Errorf("hours = %v", stats.Hours)
+		}
+		date := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.Local) }
+		thursday := Range{Start: date(12), End: date(13)}
+		if got := texts(t, s, Filter{Range: thursday}); !slices.Equal(got, []string{"utc morning"}) {
+			t.Errorf("messages on thursday = %q", got)
+		}
+		days := []DayCount{{date(11), 4}, {date(12), 1}}
+		if !slices.EqualFunc(stats.Days, days, func(a, b DayCount) bool { return a.Day.Equal(b.Day) && a.N == b.N }) {
+			t.Errorf("days = %v, want %v", stats.Days, days)
+		}
+	}},
+	{"updates", func(t *testing.T, s Store, reopen func() Store) {
+		seed(t, s)
+		mustDo(t, s.EditMessage(1, "shipped the csv importer"))
+		mustDo(t, s.ChangeTag(2, messages.NOTE))
+		mustDo(t, s.SetStatus([]int{3}, messages.DONE, start.Add(5*time.Hour)))
+		mustDo(t, s.SetPrivate([]int{1, 2}, true))
+		mustDo(t, s.SetPrivate([]int{2}, false))
+		mustDo(t, s.DeleteMessages([]int{4}))
+		for _, err := range []error{s.EditMessage(4, "gone"), s.DeleteMessages([]int{4}), s.SetStatus([]int{9}, messages.DONE, start), s.SetPrivate([]int{9}, true)} {
+			if err == nil {
+				t.Error("changing a missing message should fail")
+			}
+		}
+		// a missing id leaves the others alone
+		if s.SetPrivate([]int{3, 9}, true) == nil || s.SetStatus([]int{3, 9}, messages.CANCELLED, start) == nil || s.DeleteMessages([]int{2, 3, 9}) == nil {
+			t.Error("changing a missing message should fail")
+		}
+		if got := texts(t, s, Filter{IDs: IDRange{From: 2, To: 9}}); !slices.Equal(got, []string{"race condition in the cache", "deploy to staging"}) {
+			t.Errorf("messages 2 to 9 = %q", got)
+		}
+		if got, _ := s.Message(3); got.Private || got.Status != messages.DONE {
+			t.Error("a failed SetPrivate or SetStatus changed message 3")
+		}
+		s.Close()
+		s = reopen()
+		defer s.Close()
+
+		if got, _ := s.Message(1); got.Msg != "shipped the csv importer" || !got.Private {
+			t.Errorf("edit or private was lost: %q private %v", got.Msg, got.Private)
+		}
+		if got, _ := s.Message(2); got.Tag != messages.NOTE || got.Private {
+			t.Errorf("retag or public was lost: %v private %v", got.Tag, got.Private)
+		}
+		if got, _ := s.Message(3); got.Status != messages.DONE || !got.CompletedAt.Equal(start.Add(5*time.Hour)) {
+			t.Errorf("status was lost: %v at %v", got.Status, got.CompletedAt)
+		}
+		if got := texts(t, s, Filter{Statuses: []messages.Status{messages.OPEN}}); len(got) != 2 {
+			t.Errorf("open messages = %q, want 2", got)
+		}
+		mustDo(t, s.SetStatus([]int{3}, messages.OPEN, start))
+		if got, _ := s.Message(3); got.Status != messages.OPEN || !got.CompletedAt.IsZero() {
+			t.Errorf("reopen kept %v at %v", got.Status, got.CompletedAt)
+		}
+
+		// ids are never reused
+		msg, err := s.AddMessage(messages.Message{Timestamp: start, Tag: messages.WIN, Msg: "after delete"})
+		mustDo(t, err)
+		if msg.ID != 5 {
+			t.Errorf("id after delete = %d, want 5", msg.ID)
+		}
+	}},
+	{"stats", func(t *testing.T, s Store, _ func() Store) {
+		seed(t, s)
+		for _, msg := range []messages.Message{
+			{Timestamp: start.Add(30 * time.Hour), Tag: messages.WIN, Msg: "friday afternoon"},
+			{Timestamp: start.Add(96*time.Hour + 30*time.Minute), Tag: messages.NOTE, Msg: "monday morning"},
+		} {
+			_, err := s.AddMessage(msg)
+			mustDo(t, err)
+		}
+		stats, err := s.Stats(Filter{})
+		mustDo(t, err)
+
+		want := []TagCount{{messages.WIN, 2}, {messages.NOTE, 1}, {messages.FIX, 1}, {messages.TASK, 2}}
+		if !slices.Equal(stats.Tags, want) || stats.Total() != 6 {
+			t.Errorf("tags = %v, want %v", stats.Tags, want)
+		}
+		if stats.Weekdays != [7]int{time.Thursday: 4, time.Friday: 1, time.Monday: 1} {
+			t.Errorf("weekdays = %v", stats.Weekdays)
+		}
+		if stats.Hours != [24]int{9: 2, 10: 1, 11: 1, 12: 1, 15: 1} {
+			t.Errorf("hours = %v", stats.Hours)
+		}
+		date := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.Local) }
+		days := []DayCount{{date(12), 4}, {date(13), 1}, {date(16), 1}}
+		if !slices.EqualFunc(stats.Days, days, func(a, b DayCount) bool { return a.Day.Equal(b.Day) && a.N == b.N }) {
+			t.Errorf("days = %v, want %v", stats.Days, days)
+		}
+		if busiest := stats.Busiest(2); len(busiest) != 2 || !busiest[0].Day.Equal(date(12)) || !busiest[1].Day.Equal(date(13)) {
+			t.Errorf("busiest = %v", busiest)
+		}
+
+		monday := start.Add(102 * time.Hour)
+		if current, longest := stats.Streaks(monday); current != 1 || longest != 2 {
+			t.Errorf("streaks = %d, %d, want 1, 2", current, longest)
+		}
+		if current, _ := stats.Streaks(monday.AddDate(0, 0, 2)); current != 0 {
+			t.Errorf("streak after a day without messages = %d", current)
+		}
+		if got := stats.PerDay(Range{Start: date(12)}, monday); got != 1.2 {
+			t.Errorf("per day = %v, want 1.2", got)
+		}
+
+		tasks, err := s.Stats(Filter{Tag: messages.TASK, Range: Range{Start: start.Add(2 * time.Hour)}})
+		mustDo(t, err)
+		if tasks.Total() != 2 || len(tasks.Days) != 1 || tasks.Hours[11] != 1 {
+			t.Errorf("filtered stats = %+v", tasks)
+		}
+	}},
+	{"overdue", func(t *testing.T, s Store, _ func() Store) {
+		seed(t, s)
+		for _, tt := range []struct {
+			now  time.Time
+			want int
+		}{{start, 0}, {start.Add(3 * time.Hour), 1}, {start.Add(24 * time.Hour), 1}} {
+			if got, err := s.Overdue(tt.now); err != nil || got != tt.want {
+				t.Errorf("Overdue(%v) = %d, %v, want %d", tt.now, got, err, tt.want)
+			}
+		}
+		mustDo(t, s.SetStatus([]int{4}, messages.CANCELLED, start))
+		if got, _ := s.Overdue(start.Add(24 * time.Hour)); got != 0 {
+			t.Errorf("closed tasks are never overdue, got %d", got)
+		}
+	}},
+	{"tags", func(t *testing.T, s Store, reopen func() Store) {
+		seed(t, s)
+		deploy, err := s.AddTag(messages.TagDef{Name: "deploy", Bg: "blue", Fg: "white"})
+		mustDo(t, err)
+		if _, err := s.AddTag(messages.TagDef{Name: "DEPLOY", Bg: "red", Fg: "white"}); err == nil {
+			t.Error("tag names are case insensitive, adding DEPLOY should fail")
+		}
+		if def, ok := messages.LookupTag("deploy"); !ok || def.ID != deploy.ID {
+			t.Errorf("added tags are rendered, LookupTag(deploy) = %+v", def)
+		}
+		_, err = s.AddMessage(messages.Message{Timestamp: start, Tag: deploy.ID, Msg: "v1.4"})
+		mustDo(t, err)
+		mustDo(t, s.RenameTag(deploy.ID, "ship"))
+		if err := s.RenameTag(deploy.ID, "win"); err == nil {
+			t.Error("renaming to an existing tag should fail")
+		}
+		s.Close()
+		s = reopen()
+		defer s.Close()
+
+		tags, err := s.Tags()
+		mustDo(t, err)
+		if last := tags[len(tags)-1]; last.ID != deploy.ID || last.Name != "ship" || last.Bg != "blue" {
+			t.Errorf("last tag = %+v, want ship", last)
+		}
+		if n, _ := s.TagUsage(deploy.ID); n != 1 {
+			t.Errorf("TagUsage(ship) = %d, want 1", n)
+		}
+
+		mustDo(t, s.RemoveTag(deploy.ID, messages.NOTE))
+		if got := texts(t, s, Filter{Tag: messages.NOTE}); !slices.Equal(got, []string{"v1.4"}) {
+			t.Errorf("messages of a removed tag should be reassigned, notes = %q", got)
+		}
+		mustDo(t, s.RemoveTag(messages.TASK, messages.ANYTAG))
+		if got := texts(t, s, Filter{}); len(got) != 3 {
+			t.Errorf("purging task should leave 3 messages, got %q", got)
+		}
+		if _, ok := messages.LookupTag("task"); ok {
+			t.Error("removed tags are no longer rendered")
+		}
+
+		// tag ids are never reused either
+		again, err := s.AddTag(messages.TagDef{Name: "deploy", Bg: "blue", Fg: "white"})
+		mustDo(t, err)
+		if again.ID <= deploy.ID {
+			t.Errorf("tag id after remove = %d, want more than %d", again.ID, deploy.ID)
+		}
+	}},
+	{"settings", func(t *testing.T, s Store, reopen func() Store) {
+		mustDo(t, s.SetSetting("a", "1"))
+		mustDo(t, s.SetSetting("a", "2"))
+		mustDo(t, s.SetSetting("b", "3"))
+		mustDo(t, s.SetSetting("b", ""))
+		s.Close()
+		s = reopen()
+		defer s.Close()
+
+		for key, want := range map[string]string{"a": "2", "b": "", "c": ""} {
+			if got, err := s.Setting(key); err != nil || got != want {
+				t.Errorf("Setting(%s) = %q, %v, want %q", key, got, err, want)
+			}
+		}
+	}},
+	{"import", func(t *testing.T, s Store, reopen func() Store) {
+		seed(t, s)
+		entries := []Imported{
+			{Timestamp: start, Tag: "win", Text: "shipped the importer"}, // already there
+			{Timestamp: start.AddDate(0, 0, -1), Tag: "Deploy", Text: "v1.3"},
+			{Timestamp: start.AddDate(0, 0, -2), Tag: "note", Text: "old note"},
+			{Timestamp: start.AddDate(0, 0, -2), Tag: "note", Text: "old note"},
+		}
+		if _, err := s.Import(entries, false, false); err == nil {
+			t.Error("importing unknown tags without createTags should fail")
+		}
+
+		result, err := s.Import(entries, true, true)
+		mustDo(t, err)
+		if len(result.Added) != 2 || len(result.Duplicates) != 2 || !slices.Equal(result.NewTags, []string{"deploy"}) {
+			t.Errorf("dry run = %d added, %d duplicates, new tags %q", len(result.Added), len(result.Duplicates), result.NewTags)
+		}
+		if got := texts(t, s, Filter{}); len(got) != 4 {
+			t.Errorf("a dry run changed the store: %q", got)
+		}
+		if def, ok := messages.LookupTag("deploy"); !ok || def.ID != result.Added[0].Tag {
+			t.Error("a dry run should render the tags it would create")
+		}
+		if defs, _ := s.Tags(); len(defs) != len(messages.BuiltinTags) {
+			t.Errorf("a dry run created tags: %v", defs)
+		}
+
+		result, err = s.Import(entries, true, false)
+		mustDo(t, err)
+		if len(result.Added) != 2 || result.Added[0].ID != 5 {
+			t.Errorf("import added %+v", result.Added)
+		}
+		s.Close()
+		s = reopen()
+		defer s.Close()
+
+		if got := texts(t, s, Filter{}); !slices.Equal(got[:2], []string{"old note", "v1.3"}) || len(got) != 6 {
+			t.Errorf("after import = %q", got)
+		}
+		if _, ok := messages.LookupTag("deploy"); !ok {
+			t.Error("imported tags should be created")
+		}
+	}},
+	{"import zones", func(t *testing.T, s Store, reopen func() Store) {
+		localZone(t, time.FixedZone("EST", -5*60*60))
+		entries := []Imported{
+			{Timestamp: time.Date(2026, 3, 12, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60)), Tag: "win", Text: "tokyo"},
+			{Timestamp: time.Date(2026, 3, 12, 3, 0, 0, 0, time.UTC), Tag: "win", Text: "utc"},
+			{Timestamp: time.Date(2026, 3, 11, 20, 0, 0, 0, time.Local), Tag: "win", Text: "local"},
+			{Timestamp: time.Date(2026, 3, 11, 20, 0, 0, 0, time.Local).UTC(), Tag: "win", Text: "local"}, // the same instant
+		}
+		result, err := s.Import(entries, false, false)
+		mustDo(t, err)
+		if len(result.Added) != 3 || len(result.Duplicates) != 1 {
+			t.Errorf("import = %d added, %d duplicates", len(result.Added), len(result.Duplicates))
+		}
+		s.Close()
+		s = reopen()
+		defer s.Close()
+
+		// ordered and filtered by the local wall clock, the 11th of march in EST
+		day := Range{Start: time.Date(2026, 3, 11, 0, 0, 0, 0, time.Local), End: time.Date(2026, 3, 12, 0, 0, 0, 0, time.Local)}
+		msgs, err := s.Messages(Filter{Range: day})
+		mustDo(t, err)
+		var got []string
+		for _, msg := range msgs {
+			got = append(got, msg.Timestamp.Format("15:04 ")+msg.Msg)
+		}
+		if want := []string{"19:00 tokyo", "20:00 local", "22:00 utc"}; !slices.Equal(got, want) {
+			t.Errorf("messages of %v = %q, want %q", day, got, want)
+		}
+	}},
+	{"import tasks", func(t *testing.T, s Store, reopen func() Store) {
+		at := time.Date(2026, 3, 12, 9, 0, 0, 0, time.Local)
+		due := at.Add(48 * time.Hour)
+		_, err := s.Import([]Imported{
+			{Timestamp: at, Tag: "task", Text: "shipped", Status: messages.DONE, Due: due, Priority: messages.HIGH, Private: true},
+			{Timestamp: at, Tag: "task", Text: "open"},
+		}, false, false)
+		mustDo(t, err)
+		s.Close()
+		s = reopen()
+		defer s.Close()
+
+		msgs, err := s.Messages(Filter{})
+		mustDo(t, err)
+		if len(msgs) != 2 {
+			t.Fatalf("messages = %+v", msgs)
+		}
+		done, open := msgs[0], msgs[1]
+		if done.Status != messages.DONE || !done.CompletedAt.Equal(at) || !done.Due.Equal(due) || done.Priority != messages.HIGH || !done.Private {
+			t.Errorf("imported done task = %+v", done)
+		}
+		if open.Status != messages.OPEN || !open.CompletedAt.IsZero() || !open.Due.IsZero() || open.Priority != messages.NORMAL || open.Private {
+			t.Errorf("imported open task = %+v", open)
+		}
+	}},
+}
+
+// TestConformance runs the same tests against every Store
+func TestConformance(t *testing.T) {
+	for _, b := range backends {
+		for _, c := range conformance {
+			t.Run(b.name+"/"+c.name, func(t *testing.T) {
+				s, reopen := b.open(t)
+				c.test(t, s, reopen)
+				s.Close()
+			})
+		}
+	}
+}
+
+func TestOpenJSONLRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.jsonl")
+	s := openOrFail(t, path)
+	s.Close()
+	if _, err := OpenJSONL(path); err != nil {
+		t.Fatalf("reopening an empty log: %v", err)
+	}
+
+	other := filepath.Join("..", "go.mod")
+	if _, err := OpenJSONL(other); err == nil {
+		t.Error("a file that isn't a mindtick log should be rejected")
+	}
+}
+
+// jsonlWriterEnv makes the test binary a writer process adding to the log in it
+const jsonlWriterEnv = "MINDTICK_JSONL_WRITER"
+
+const jsonlWriters, jsonlWrites = 4, 25
+
+// TestJSONLSharedLog writes to one log through several handles, like mindticks
+// started by git hooks and an editor at once
+func TestJSONLSharedLog(t *testing.T) {
+	if path := os.Getenv(jsonlWriterEnv); path != "" {
+		s, err := OpenJSONL(path)
+		for i := 0; err == nil && i < jsonlWrites; i++ {
+			_, err = s.AddMessage(messages.Message{Timestamp: start, Tag: messages.NOTE, Msg: fmt.Sprintf("writer %s %d", os.Getenv(jsonlWriterEnv+"_ID"), i)})
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		s.Close()
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), JSONLFileName)
+	a, b := openOrFail(t, path), openOrFail(t, path)
+	defer a.Close()
+	defer b.Close()
+
+	first, err := a.AddMessage(messages.Message{Timestamp: start, Tag: messages.WIN, Msg: "from a"})
+	mustDo(t, err)
+	second, err := b.AddMessage(messages.Message{Timestamp: start, Tag: messages.FIX, Msg: "from b"})
+	mustDo(t, err)
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("ids = %d, %d, want 1, 2", first.ID, second.ID)
+	}
+	mustDo(t, b.EditMessage(1, "from a, edited by b"))
+	mustDo(t, a.SetPrivate([]int{2}, true))
+	// a tag added through one handle is found through the other
+	deploy, err := a.AddTag(messages.TagDef{Name: "deploy", Bg: "blue", Fg: "white"})
+	mustDo(t, err)
+	mustDo(t, b.ChangeTag(2, deploy.ID))
+	mustDo(t, b.RenameTag(deploy.ID, "release"))
+	mustDo(t, a.RemoveTag(deploy.ID, messages.NOTE))
+
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+	var procs []*exec.Cmd
+	outputs := make([]bytes.Buffer, jsonlWriters)
+	for i := range jsonlWriters {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestJSONLSharedLog$")
+		cmd.Env = append(os.Environ(), jsonlWriterEnv+"="+path, jsonlWriterEnv+"_ID="+strconv.Itoa(i))
+		cmd.Stdout, cmd.Stderr = &outputs[i], &outputs[i]
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		procs = append(procs, cmd)
+	}
+	for i, cmd := range procs {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("process %d: %v\n%s", i, err, outputs[i].String())
+		}
+	}
+
+	s := openOrFail(t, path)
+	defer s.Close()
+	msgs, err := s.Messages(Filter{})
+	mustDo(t, err)
+	ids := map[int]bool{}
+	for _, msg := range msgs {
+		ids[msg.ID] = true
+	}
+	if len(msgs) != 2+jsonlWriters*jsonlWrites || len(ids) != len(msgs) {
+		t.Errorf("%d messages with %d distinct ids, want %d", len(msgs), len(ids), 2+jsonlWriters*jsonlWrites)
+	}
+	if got, _ := s.Message(1); got.Msg != "from a, edited by b" {
+		t.Errorf("edit through the other handle was lost: %q", got.Msg)
+	}
+	if got, _ := s.Message(2); !got.Private || got.Tag != messages.NOTE {
+		t.Errorf("private and tag changes through the other handle were lost: %+v", got)
+	}
+}
+
+// TestJSONLMergedIDs opens a log merged from two branches that both added id 2,
+// the events of each branch follow its own message by uid
+func TestJSONLMergedIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), JSONLFileName)
+	log := `{"op":"mindtick","version":1}
+{"op":"add","id":1,"uid":"00","timestamp":"2026-03-12T09:00:00Z","tag":"win","text":"on main"}
+{"op":"add","id":2,"uid":"aa","timestamp":"2026-03-12T10:00:00Z","tag":"win","text":"on feature a"}
+{"op":"edit","id":2,"uid":"aa","text":"edited on feature a"}
+{"op":"add","id":2,"uid":"bb","timestamp":"2026-03-12T11:00:00Z","tag":"fix","text":"on feature b"}
+{"op":"edit","id":2,"uid":"bb","text":"edited on feature b"}
+{"op":"add","id":3,"uid":"cc","timestamp":"2026-03-12T12:00:00Z","tag":"fix","text":"kept on feature b"}
+{"op":"delete","id":2,"uid":"bb"}
+`
+	if err := os.WriteFile(path, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := openOrFail(t, path)
+	want := []string{"on main", "edited on feature a", "kept on feature b"}
+	if got := texts(t, s, Filter{}); !slices.Equal(got, want) {
+		t.Fatalf("merged log = %q, want %q", got, want)
+	}
+	if got, _ := s.Message(4); got.Msg != "kept on feature b" {
+		t.Errorf("the colliding adds should get the next ids, 4 is %q", got.Msg)
+	}
+
+	// changes after the merge use the new ids and replay the same way
+	msg, err := s.AddMessage(messages.Message{Timestamp: start, Tag: messages.NOTE, Msg: "after the merge"})
+	mustDo(t, err)
+	if msg.ID != 5 {
+		t.Errorf("id after the merge = %d, want 5", msg.ID)
+	}
+	mustDo(t, s.EditMessage(4, "edited after the merge"))
+	mustDo(t, s.DeleteMessages([]int{2}))
+	s.Close()
+	s = openOrFail(t, path)
+	defer s.Close()
+	want = []string{"on main", "after the merge", "edited after the merge"}
+	if got := texts(t, s, Filter{}); !slices.Equal(got, want) {
+		t.Errorf("reopened = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// queryer is a *sql.DB or a *sql.Tx
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func (s *SQLite) Tags() ([]messages.TagDef, error) {
+	return tags(s.db)
+}
+
+func tags(db queryer) ([]messages.TagDef, error) {
+	rows, err := db.Query("SELECT id, name, bg, fg FROM tags ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var defs []messages.TagDef
+	for rows.Next() {
+		var def messages.TagDef
+		if err := rows.Scan(&def.ID, &def.Name, &def.Bg, &def.Fg); err != nil {
+			return nil, fmt.Errorf("unable to scan tags: %v", err)
+		}
+		defs = append(defs, def)
+	}
+	return defs, rows.Err()
+}
+
+// loadTags makes the tags of the store the ones messages resolves and renders,
+// db is the store or the transaction changing its tags
+func (s *SQLite) loadTags(db queryer) error {
+	defs, err := tags(db)
+	if err != nil {
+		return err
+	}
+	messages.SetTags(defs)
+	return nil
+}
+
+// `mindtick tag add` command
+func (s *SQLite) AddTag(def messages.TagDef) (messages.TagDef, error) {
+	if _, ok := messages.LookupTag(def.Name); ok {
+		return def, fmt.Errorf("tag %s already exists", messages.ColorizeStr(def.Name, messages.BrightPurple))
+	}
+
+	res, err := s.exec("INSERT INTO tags (name, bg, fg) VALUES (?, ?, ?)", def.Name, def.Bg, def.Fg)
+	if err != nil {
+		return def, fmt.Errorf("unable to add tag: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return def, fmt.Errorf("unable to add tag: %v", err)
+	}
+	def.ID = messages.Tag(id)
+	return def, s.loadTags(s.db)
+}
+
+// `mindtick tag rename` command
+func (s *SQLite) RenameTag(tag messages.Tag, name string) error {
+	if def, ok := messages.LookupTag(name); ok && def.ID != tag {
+		return fmt.Errorf("tag %s already exists", messages.ColorizeStr(name, messages.BrightPurple))
+	}
+	if _, err := s.exec("UPDATE tags SET name = ? WHERE id = ?", name, tag); err != nil {
+		return fmt.Errorf("unable to rename tag: %v", err)
+	}
+	return s.loadTags(s.db)
+}
+
+func (s *SQLite) TagUsage(tag messages.Tag) (int, error) {
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM messages WHERE msgtype = ?", tag).Scan(&count); err != nil {
+		return 0, fmt.Errorf("unable to count messages: %v", err)
+	}
+	return count, nil
+}
+
+// `mindtick tag remove` command
+func (s *SQLite) RemoveTag(tag messages.Tag, reassign messages.Tag) error {
+	tx, err := begin(s.db)
+	if err != nil {
+		return fmt.Errorf("unable to remove tag: %v", err)
+	}
+	defer tx.Rollback()
+
+	if reassign != messages.ANYTAG {
+		_, err = tx.Exec("UPDATE messages SET msgtype = ? WHERE msgtype = ?", reassign, tag)
+	} else {
+		_, err = tx.Exec("DELETE FROM messages WHERE msgtype = ?", tag)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update messages of removed tag: %v", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM tags WHERE id = ?", tag); err != nil {
+		return fmt.Errorf("unable to remove tag: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to remove tag: %v", err)
+	}
+	return s.loadTags(s.db)
+}
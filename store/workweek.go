@@ -0,0 +1,55 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ninesl/mindtick/messages"
+)
+
+// WorkWeek marks the days of the week someone works, indexed by time.Weekday
+type WorkWeek [7]bool
+
+// DefaultWorkWeek is monday to friday
+const DefaultWorkWeek = "mon-fri"
+
+// ParseWorkWeek parses comma separated weekdays and ranges of weekdays,
+// like mon-fri, sun-thu or mon,tue,thu. Ranges may wrap around the weekend, fri-mon.
+func ParseWorkWeek(expr string) (WorkWeek, error) {
+	var w WorkWeek
+	invalid := fmt.Errorf("invalid work week %s, try %s", messages.ColorizeStr(expr, messages.BrightPurple), messages.ColorizeStr("mon-fri or mon,tue,thu", messages.BrightGreen))
+	for _, part := range strings.Split(strings.ToLower(expr), ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, ok := weekdays[from]
+		if !ok {
+			return w, invalid
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[to]; !ok {
+				return w, invalid
+			}
+		}
+		for wd := first; ; wd = (wd + 1) % 7 {
+			w[wd] = true
+			if wd == last {
+				break
+			}
+		}
+	}
+	return w, nil
+}
+
+// LastWorkday is the day before now, skipping back over the days not worked,
+// so on a monday it is friday. It is yesterday when no day is worked.
+func (w WorkWeek) LastWorkday(now time.Time) Range {
+	d := now.AddDate(0, 0, -1)
+	for i := 0; i < 7 && !w[d.Weekday()]; i++ {
+		d = d.AddDate(0, 0, -1)
+	}
+	if !w[d.Weekday()] {
+		return day(now.AddDate(0, 0, -1))
+	}
+	return day(d)
+}